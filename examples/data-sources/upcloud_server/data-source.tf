@@ -0,0 +1,35 @@
+# Look up a server that is not managed by Terraform by its hostname
+data "upcloud_server" "legacy" {
+  hostname = "legacy.example.com"
+}
+
+# Look up a server by its labels
+data "upcloud_server" "web" {
+  zone = "fi-hel1"
+  labels = {
+    env  = "prod"
+    role = "web"
+  }
+}
+
+# Allow SSH access to the legacy server only from the web server
+resource "upcloud_firewall_rules" "legacy" {
+  server_id = data.upcloud_server.legacy.id
+
+  firewall_rule {
+    action                 = "accept"
+    comment                = "Allow SSH from web server"
+    destination_port_end   = "22"
+    destination_port_start = "22"
+    direction              = "in"
+    family                 = "IPv4"
+    protocol               = "tcp"
+    source_address_start   = data.upcloud_server.web.network_interface[0].ip_address
+    source_address_end     = data.upcloud_server.web.network_interface[0].ip_address
+  }
+
+  firewall_rule {
+    action    = "drop"
+    direction = "in"
+  }
+}
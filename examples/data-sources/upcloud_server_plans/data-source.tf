@@ -0,0 +1,20 @@
+# Select the smallest plan with at least 4 CPU cores and 8 GB of memory
+data "upcloud_server_plans" "app" {
+  min_cpu           = 4
+  min_memory_gb     = 8
+  smallest_matching = true
+}
+
+resource "upcloud_server" "app" {
+  hostname = "app.example.tld"
+  zone     = "de-fra1"
+  plan     = data.upcloud_server_plans.app.plans[0].name
+
+  template {
+    storage = "Ubuntu Server 22.04 LTS (Jammy Jellyfish)"
+  }
+
+  network_interface {
+    type = "public"
+  }
+}
@@ -0,0 +1,23 @@
+# List started production web servers in fi-hel1
+data "upcloud_servers" "web" {
+  zone           = "fi-hel1"
+  state          = "started"
+  hostname_regex = "^web-"
+  label_selector = [
+    "env=prod",
+    "role in (web, api)",
+  ]
+}
+
+# Add each of the servers to a load balancer backend
+resource "upcloud_loadbalancer_static_backend_member" "web" {
+  for_each = { for server in data.upcloud_servers.web.servers : server.hostname => server }
+
+  backend      = upcloud_loadbalancer_backend.web.id
+  name         = each.value.id
+  ip           = one([for ip in each.value.ip_addresses : ip.address if ip.type == "private"])
+  port         = 8080
+  weight       = 100
+  max_sessions = 1000
+  enabled      = true
+}
@@ -0,0 +1,8 @@
+provider "upcloud" {
+  # Labels added to every resource that supports labels
+  default_labels = {
+    team        = "platform"
+    cost_center = "1234"
+    env         = "production"
+  }
+}
@@ -0,0 +1,13 @@
+terraform {
+  required_providers {
+    upcloud = {
+      source  = "UpCloudLtd/upcloud"
+      version = "~> 2.0"
+    }
+  }
+}
+
+provider "upcloud" {
+  # Read credentials from the production profile of ~/.config/upcloud/credentials.yaml
+  profile = "production"
+}
@@ -0,0 +1,4 @@
+provider "upcloud" {
+  # Zone for resources that do not define a zone
+  zone = "de-fra1"
+}
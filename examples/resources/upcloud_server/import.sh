@@ -1 +1,3 @@
-terraform import upcloud_server.example_server ead4544f-10bf-42a3-b98a-a0fea2e2ad14
\ No newline at end of file
+# The boot disk of the server is imported as the template. Other storage devices are imported as storage_devices.
+# The template the server was created from and the login and user_data arguments cannot be determined, so changes to them are ignored for imported servers.
+terraform import upcloud_server.example_server ead4544f-10bf-42a3-b98a-a0fea2e2ad14
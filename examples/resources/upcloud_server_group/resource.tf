@@ -12,3 +12,15 @@ resource "upcloud_server_group" "main" {
     "000012dc-fe8c-a3y6-91f9-0db1215c36cf"
   ]
 }
+
+# Servers labelled with `anti-affinity-group = "web"` join the group, also when they are managed in another configuration
+resource "upcloud_server_group" "web" {
+  title                = "web_group"
+  anti_affinity_policy = "strict"
+  enforce_policy       = "restart"
+  member_selector {
+    labels = {
+      "anti-affinity-group" = "web"
+    }
+  }
+}
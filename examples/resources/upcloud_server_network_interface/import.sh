@@ -0,0 +1,2 @@
+# The ID is the UUID of the server and the index of the interface separated by a slash
+terraform import upcloud_server_network_interface.example 00b4d3a5-fbb2-4b0f-8f5a-4d9ae4bb5c3e/2
@@ -0,0 +1,35 @@
+resource "upcloud_server" "example" {
+  hostname = "terraform.example.tld"
+  zone     = "de-fra1"
+  plan     = "1xCPU-1GB"
+
+  # Do not remove the interfaces managed with upcloud_server_network_interface resources
+  ignore_external_network_interfaces = true
+
+  template {
+    storage = "Ubuntu Server 22.04 LTS (Jammy Jellyfish)"
+  }
+
+  network_interface {
+    type = "public"
+  }
+}
+
+resource "upcloud_network" "example" {
+  name = "example-private-net"
+  zone = "de-fra1"
+
+  ip_network {
+    address = "10.0.0.0/24"
+    dhcp    = true
+    family  = "IPv4"
+  }
+}
+
+# Attach the server to a private network with a fixed IP address
+resource "upcloud_server_network_interface" "example" {
+  server_id  = upcloud_server.example.id
+  type       = "private"
+  network    = upcloud_network.example.id
+  ip_address = "10.0.0.10"
+}
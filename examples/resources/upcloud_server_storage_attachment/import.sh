@@ -0,0 +1,2 @@
+# The ID is the UUID of the server and the UUID of the storage separated by a slash
+terraform import upcloud_server_storage_attachment.data 00b4d3a5-fbb2-4b0f-8f5a-4d9ae4bb5c3e/01c8df16-a7c8-4d3b-9a1b-5a2b5a2f1f7e
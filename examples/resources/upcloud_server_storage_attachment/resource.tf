@@ -0,0 +1,30 @@
+resource "upcloud_server" "blue" {
+  hostname = "blue.example.tld"
+  zone     = "de-fra1"
+  plan     = "1xCPU-1GB"
+
+  # Do not detach the storages managed with upcloud_server_storage_attachment resources
+  ignore_external_storage_devices = true
+
+  template {
+    storage = "Ubuntu Server 22.04 LTS (Jammy Jellyfish)"
+  }
+
+  network_interface {
+    type = "public"
+  }
+}
+
+resource "upcloud_storage" "data" {
+  size  = 10
+  tier  = "maxiops"
+  title = "data"
+  zone  = "de-fra1"
+}
+
+# Changing server_id moves the storage to another server
+resource "upcloud_server_storage_attachment" "data" {
+  server_id = upcloud_server.blue.id
+  storage   = upcloud_storage.data.id
+  address   = "virtio"
+}
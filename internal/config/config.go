@@ -1,4 +1,24 @@
 package config
 
+import (
+	"time"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/mutexkv"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+)
+
 // Version contains the current software version from git.
 var Version = "dev"
+
+// Meta is the meta value that the provider passes to resource and data source operations.
+type Meta struct {
+	Service *service.Service
+	// DefaultLabels are merged into the labels of every resource that supports labels.
+	DefaultLabels map[string]string
+	// DefaultZone is used as the zone of resources that do not define a zone.
+	DefaultZone string
+	// ServerLocks serializes operations that modify the same server, keyed by server UUID.
+	ServerLocks *mutexkv.MutexKV
+	// PollInterval is the interval for polling the state of resources while waiting for them. Defaults to 5 seconds.
+	PollInterval time.Duration
+}
@@ -0,0 +1,47 @@
+// Package mutexkv provides a key-value store of mutexes that is used to serialize operations on the same remote
+// resource, e.g. a server that several Terraform resources modify.
+package mutexkv
+
+import (
+	"sync"
+)
+
+// MutexKV is a simple key/value store for arbitrary mutexes. It can be used to serialize changes across arbitrary
+// collaborators that share knowledge of the keys they must serialize on. Zero value is ready to use.
+type MutexKV struct {
+	lock  sync.Mutex
+	store map[string]*sync.Mutex
+}
+
+// New returns a properly initialized MutexKV.
+func New() *MutexKV {
+	return &MutexKV{
+		store: make(map[string]*sync.Mutex),
+	}
+}
+
+// Lock locks the mutex for the given key. Caller is responsible for calling Unlock for the same key.
+func (m *MutexKV) Lock(key string) {
+	m.get(key).Lock()
+}
+
+// Unlock unlocks the mutex for the given key. Caller must have called Lock for the same key first.
+func (m *MutexKV) Unlock(key string) {
+	m.get(key).Unlock()
+}
+
+// get returns a mutex for the given key, creating one if it does not exist yet.
+func (m *MutexKV) get(key string) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.store == nil {
+		m.store = make(map[string]*sync.Mutex)
+	}
+	mutex, ok := m.store[key]
+	if !ok {
+		mutex = &sync.Mutex{}
+		m.store[key] = mutex
+	}
+	return mutex
+}
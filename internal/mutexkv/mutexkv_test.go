@@ -0,0 +1,61 @@
+package mutexkv
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutexKV_serializesSameKey(t *testing.T) {
+	m := New()
+	var (
+		mu      sync.Mutex
+		active  int
+		maxSeen int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Lock("server")
+			defer m.Unlock("server")
+
+			mu.Lock()
+			active++
+			if active > maxSeen {
+				maxSeen = active
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, maxSeen)
+}
+
+func TestMutexKV_independentKeys(t *testing.T) {
+	var m MutexKV
+	m.Lock("a")
+	defer m.Unlock("a")
+
+	done := make(chan struct{})
+	go func() {
+		m.Lock("b")
+		m.Unlock("b")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock of a different key was blocked")
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -87,7 +87,7 @@ func schemaOpenSearchIndex() *schema.Resource {
 }
 
 func dataSourceOpenSearchIndicesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	serviceID := d.Get("service").(string)
 
 	indices, err := client.GetManagedDatabaseIndices(ctx, &request.GetManagedDatabaseIndicesRequest{
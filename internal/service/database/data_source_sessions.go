@@ -4,11 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -385,7 +385,7 @@ func dataSourceSessionsRedisRead(ctx context.Context, d *schema.ResourceData, me
 }
 
 func dataSourceSessionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}, serviceType upcloud.ManagedDatabaseServiceType) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	serviceID := d.Get("service").(string)
 
 	limit := d.Get("limit").(int)
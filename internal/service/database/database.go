@@ -7,6 +7,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
@@ -22,7 +23,7 @@ var resourceUpcloudManagedDatabaseModifiableStates = []upcloud.ManagedDatabaseSt
 }
 
 func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	req := buildManagedDatabaseRequestFromResourceData(d)
 
 	details, err := client.CreateManagedDatabase(ctx, &req)
@@ -66,7 +67,7 @@ func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta in
 
 func resourceDatabaseRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
 	var err error
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	req := request.GetManagedDatabaseRequest{UUID: d.Id()}
 	details, err := client.GetManagedDatabase(ctx, &req)
 	if err != nil {
@@ -92,7 +93,7 @@ func resourceDatabaseRead(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	diags := diag.Diagnostics{}
 
 	if d.HasChanges("plan", "title", "zone",
@@ -131,7 +132,7 @@ func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta in
 }
 
 func resourceDatabasePoweredUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var err error
 	var msg string
@@ -152,7 +153,11 @@ func resourceDatabasePoweredUpdate(ctx context.Context, d *schema.ResourceData,
 }
 
 func resourceDatabaseDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
+	client := meta.(*config.Meta).Service
 
 	req := request.DeleteManagedDatabaseRequest{UUID: d.Id()}
 	if err := client.DeleteManagedDatabase(ctx, &req); err != nil {
@@ -308,12 +313,11 @@ func resourceUpCloudManagedDatabaseSetCommonState(d *schema.ResourceData, detail
 }
 
 func waitManagedDatabaseFullyCreated(ctx context.Context, client *service.Service, db *upcloud.ManagedDatabase) error {
-	const maxRetries int = 100
 	var err error
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fmt.Errorf("timeout reached while waiting for managed database instance to be created: %w", ctx.Err())
 		default:
 			if db, err = client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: db.UUID}); err != nil {
 				return err
@@ -324,7 +328,6 @@ func waitManagedDatabaseFullyCreated(ctx context.Context, client *service.Servic
 		}
 		time.Sleep(5 * time.Second)
 	}
-	return errors.New("max retries reached while waiting for managed database instance to be created")
 }
 
 func waitServiceNameToPropagate(ctx context.Context, name string) (err error) {
@@ -364,7 +367,7 @@ func updateDatabaseVersion(ctx context.Context, d *schema.ResourceData, client *
 	// Attempt to upgrade version after database is powered on
 	// Upgrade is only allowed when database is in "Running" state, so we have to wait for that after powering it on
 	if d.HasChange("powered") && d.Get("powered").(bool) {
-		_, err := resourceUpCloudManagedDatabaseWaitState(ctx, d.Id(), client, time.Minute*15, upcloud.ManagedDatabaseStateRunning)
+		_, err := resourceUpCloudManagedDatabaseWaitState(ctx, d.Id(), client, d.Timeout(schema.TimeoutUpdate), upcloud.ManagedDatabaseStateRunning)
 		if err != nil {
 			return append(diags, diag.Diagnostic{
 				Severity: diag.Warning,
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -98,10 +99,12 @@ func schemaDatabaseCommon() map[string]*schema.Schema {
 			Computed:    true,
 		},
 		"zone": {
-			Description: "Zone where the instance resides, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
+			Description: "Zone where the instance resides, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
 			Type:        schema.TypeString,
-			Required:    true,
+			Optional:    true,
+			Computed:    true,
 		},
+		"deletion_protection": utils.DeletionProtectionSchema("managed database"),
 		"primary_database": {
 			Description: "Primary database name",
 			Type:        schema.TypeString,
@@ -4,11 +4,12 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -16,10 +17,11 @@ import (
 )
 
 func ResourceLogicalDatabase() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents a logical database in managed database",
 		CreateContext: resourceLogicalDatabaseCreate,
 		ReadContext:   resourceLogicalDatabaseRead,
+		UpdateContext: resourceLogicalDatabaseUpdate,
 		DeleteContext: resourceLogicalDatabaseDelete,
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) ([]*schema.ResourceData, error) {
@@ -36,8 +38,14 @@ func ResourceLogicalDatabase() *schema.Resource {
 				return []*schema.ResourceData{data}, nil
 			},
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: schemaLogicalDatabase(),
 	}
+	r.CustomizeDiff = utils.PreventProtectedReplacement(r.Schema)
+	return r
 }
 
 func schemaLogicalDatabase() map[string]*schema.Schema {
@@ -70,11 +78,12 @@ func schemaLogicalDatabase() map[string]*schema.Schema {
 			ForceNew:         true,
 			ValidateDiagFunc: validation.ToDiagFunc(validateManagedDatabaseLocale),
 		},
+		"deletion_protection": utils.DeletionProtectionSchema("logical database"),
 	}
 }
 
 func resourceLogicalDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	serviceID := d.Get("service").(string)
 	serviceDetails, err := client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: serviceID})
@@ -112,7 +121,7 @@ func resourceLogicalDatabaseCreate(ctx context.Context, d *schema.ResourceData,
 }
 
 func resourceLogicalDatabaseRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	serviceID, name := splitManagedDatabaseSubResourceID(d.Id())
 
@@ -144,8 +153,18 @@ func resourceLogicalDatabaseRead(ctx context.Context, d *schema.ResourceData, me
 	return copyLogicalDatabaseDetailsToResource(d, details)
 }
 
+// resourceLogicalDatabaseUpdate only updates the state, as deletion_protection is the only argument that can be changed
+// without replacing the logical database.
+func resourceLogicalDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceLogicalDatabaseRead(ctx, d, meta)
+}
+
 func resourceLogicalDatabaseDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
+	client := meta.(*config.Meta).Service
 
 	serviceID := d.Get("service").(string)
 	serviceDetails, err := client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: serviceID})
@@ -158,7 +177,7 @@ func resourceLogicalDatabaseDelete(ctx context.Context, d *schema.ResourceData,
 
 	serviceID, name := splitManagedDatabaseSubResourceID(d.Id())
 	serviceDetails, err = resourceUpCloudManagedDatabaseWaitState(ctx, serviceID, meta,
-		d.Timeout(schema.TimeoutCreate), resourceUpcloudManagedDatabaseModifiableStates...)
+		d.Timeout(schema.TimeoutDelete), resourceUpcloudManagedDatabaseModifiableStates...)
 	if err != nil {
 		return diag.FromErr(err)
 	}
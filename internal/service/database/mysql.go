@@ -4,16 +4,18 @@ import (
 	"context"
 	"math"
 	"regexp"
+	"time"
 
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourceMySQL() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents MySQL managed database",
 		CreateContext: resourceMySQLCreate,
 		ReadContext:   resourceMySQLRead,
@@ -22,11 +24,21 @@ func ResourceMySQL() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: utils.JoinSchemas(
 			schemaDatabaseCommon(),
 			schemaMySQLEngine(),
 		),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func resourceMySQLCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -2,18 +2,20 @@ package database
 
 import (
 	"context"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourceOpenSearch() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents OpenSearch managed database",
 		CreateContext: resourceOpenSearchCreate,
 		ReadContext:   resourceOpenSearchRead,
@@ -22,12 +24,22 @@ func ResourceOpenSearch() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: utils.JoinSchemas(
 			schemaDatabaseCommon(),
 			schemaOpenSearchEngine(),
 			schemaOpenSearchAccessControl(),
 		),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func resourceOpenSearchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -41,7 +53,7 @@ func resourceOpenSearchCreate(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	if d.HasChanges("access_control", "extended_access_control") {
-		client := meta.(*service.Service)
+		client := meta.(*config.Meta).Service
 		aclReq := request.ModifyManagedDatabaseAccessControlRequest{
 			ServiceUUID:         d.Id(),
 			ACLsEnabled:         upcloud.BoolPtr(d.Get("access_control").(bool)),
@@ -62,7 +74,7 @@ func resourceOpenSearchRead(ctx context.Context, d *schema.ResourceData, meta in
 		return diags
 	}
 
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	aclReq := request.GetManagedDatabaseAccessControlRequest{ServiceUUID: d.Id()}
 	acl, err := client.GetManagedDatabaseAccessControl(ctx, &aclReq)
 	if err != nil {
@@ -80,7 +92,7 @@ func resourceOpenSearchRead(ctx context.Context, d *schema.ResourceData, meta in
 
 func resourceOpenSearchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	if d.HasChanges("access_control", "extended_access_control") {
-		client := meta.(*service.Service)
+		client := meta.(*config.Meta).Service
 		aclReq := request.ModifyManagedDatabaseAccessControlRequest{
 			ServiceUUID:         d.Id(),
 			ACLsEnabled:         upcloud.BoolPtr(d.Get("access_control").(bool)),
@@ -4,17 +4,19 @@ import (
 	"context"
 	"math"
 	"regexp"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourcePostgreSQL() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents PostgreSQL managed database",
 		CreateContext: resourcePostgreSQLCreate,
 		ReadContext:   resourcePostgreSQLRead,
@@ -23,11 +25,21 @@ func ResourcePostgreSQL() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: utils.JoinSchemas(
 			schemaDatabaseCommon(),
 			schemaPostgreSQLEngine(),
 		),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func resourcePostgreSQLCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -53,7 +65,7 @@ func resourcePostgreSQLUpdate(ctx context.Context, d *schema.ResourceData, meta
 		return diags
 	}
 
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	if !d.HasChange("powered") {
 		if d.HasChange("properties.0.version") {
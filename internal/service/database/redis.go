@@ -3,16 +3,18 @@ package database
 import (
 	"context"
 	"regexp"
+	"time"
 
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourceRedis() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents Redis managed database",
 		CreateContext: resourceRedisCreate,
 		ReadContext:   resourceRedisRead,
@@ -21,11 +23,21 @@ func ResourceRedis() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: utils.JoinSchemas(
 			schemaDatabaseCommon(),
 			schemaRedisEngine(),
 		),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func resourceRedisCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
@@ -15,7 +17,7 @@ import (
 )
 
 func ResourceUser() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents a user in managed database",
 		CreateContext: resourceUserCreate,
 		ReadContext:   resourceUserRead,
@@ -36,8 +38,15 @@ func ResourceUser() *schema.Resource {
 				return []*schema.ResourceData{data}, nil
 			},
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: schemaUser(),
 	}
+	r.CustomizeDiff = utils.PreventProtectedReplacement(r.Schema)
+	return r
 }
 
 func schemaUser() map[string]*schema.Schema {
@@ -105,6 +114,7 @@ func schemaUser() map[string]*schema.Schema {
 				Schema: schemaOpenSearchUserAccessControl(),
 			},
 		},
+		"deletion_protection": utils.DeletionProtectionSchema("user"),
 	}
 }
 
@@ -192,7 +202,7 @@ func schemaOpenSearchUserAccessControl() map[string]*schema.Schema {
 }
 
 func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	if d.HasChange("type") && d.Get("type").(string) != string(upcloud.ManagedDatabaseUserTypeNormal) {
 		return diag.FromErr(fmt.Errorf("only type `normal` users can be created"))
@@ -247,7 +257,7 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	serviceID, username := splitManagedDatabaseSubResourceID(d.Id())
 
@@ -271,7 +281,12 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interfac
 }
 
 func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+
+	// deletion_protection is only stored in the state
+	if !d.HasChangeExcept("deletion_protection") {
+		return resourceUserRead(ctx, d, meta)
+	}
 
 	serviceID := d.Get("service").(string)
 	serviceDetails, err := client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: serviceID})
@@ -284,7 +299,7 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 
 	serviceID, username := splitManagedDatabaseSubResourceID(d.Id())
 	serviceDetails, err = resourceUpCloudManagedDatabaseWaitState(ctx, serviceID, meta,
-		d.Timeout(schema.TimeoutCreate), resourceUpcloudManagedDatabaseModifiableStates...)
+		d.Timeout(schema.TimeoutUpdate), resourceUpcloudManagedDatabaseModifiableStates...)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -331,7 +346,7 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	if d.Get("type").(string) == string(upcloud.ManagedDatabaseUserTypePrimary) {
 		if d.HasChange("username") {
@@ -341,6 +356,10 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 		return nil
 	}
 
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
 	serviceID := d.Get("service").(string)
 	serviceDetails, err := client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: serviceID})
 	if err != nil {
@@ -352,7 +371,7 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 
 	serviceID, username := splitManagedDatabaseSubResourceID(d.Id())
 	serviceDetails, err = resourceUpCloudManagedDatabaseWaitState(ctx, serviceID, meta,
-		d.Timeout(schema.TimeoutCreate), resourceUpcloudManagedDatabaseModifiableStates...)
+		d.Timeout(schema.TimeoutDelete), resourceUpcloudManagedDatabaseModifiableStates...)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -6,9 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -20,7 +20,7 @@ func resourceUpCloudManagedDatabaseWaitState(
 	timeout time.Duration,
 	targetStates ...upcloud.ManagedDatabaseState,
 ) (*upcloud.ManagedDatabase, error) {
-	client := m.(*service.Service)
+	client := m.(*config.Meta).Service
 	refresher := func() (result interface{}, state string, err error) {
 		resp, err := client.GetManagedDatabase(ctx, &request.GetManagedDatabaseRequest{UUID: id})
 		if err != nil {
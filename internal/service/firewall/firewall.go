@@ -6,9 +6,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -29,6 +30,11 @@ func ResourceFirewallRules() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 5),
+			Update: schema.DefaultTimeout(time.Minute * 5),
+			Delete: schema.DefaultTimeout(time.Minute * 5),
+		},
 		Schema: map[string]*schema.Schema{
 			"server_id": {
 				Type:        schema.TypeString,
@@ -154,7 +160,8 @@ func ResourceFirewallRules() *schema.Resource {
 }
 
 func resourceFirewallRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, d.Get("server_id").(string))()
 
 	opts := &request.CreateFirewallRulesRequest{
 		ServerUUID: d.Get("server_id").(string),
@@ -188,15 +195,13 @@ func resourceFirewallRulesCreate(ctx context.Context, d *schema.ResourceData, me
 		opts.FirewallRules = firewallRules
 	}
 
-	if _, err := client.WaitForServerState(ctx, &request.WaitForServerStateRequest{
-		UUID:           opts.ServerUUID,
-		UndesiredState: upcloud.ServerStateMaintenance,
-		Timeout:        time.Minute * 5,
-	}); err != nil {
+	if _, err := utils.WaitForServerToSettle(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutCreate)); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := client.CreateFirewallRules(ctx, opts); err != nil {
+	if err := utils.RetryWhileServerBusy(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutCreate), func() error {
+		return client.CreateFirewallRules(ctx, opts)
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -206,7 +211,7 @@ func resourceFirewallRulesCreate(ctx context.Context, d *schema.ResourceData, me
 }
 
 func resourceFirewallRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -254,13 +259,16 @@ func resourceFirewallRulesRead(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceFirewallRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, d.Id())()
 
 	opts := &request.CreateFirewallRulesRequest{
 		ServerUUID: d.Id(),
 	}
 
-	err := client.CreateFirewallRules(ctx, opts)
+	err := utils.RetryWhileServerBusy(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutUpdate), func() error {
+		return client.CreateFirewallRules(ctx, opts)
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -295,8 +303,9 @@ func resourceFirewallRulesUpdate(ctx context.Context, d *schema.ResourceData, me
 		opts.FirewallRules = firewallRules
 	}
 
-	err = client.CreateFirewallRules(ctx, opts)
-
+	err = utils.RetryWhileServerBusy(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutUpdate), func() error {
+		return client.CreateFirewallRules(ctx, opts)
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -305,7 +314,8 @@ func resourceFirewallRulesUpdate(ctx context.Context, d *schema.ResourceData, me
 }
 
 func resourceFirewallRulesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, d.Id())()
 
 	var diags diag.Diagnostics
 
@@ -314,15 +324,13 @@ func resourceFirewallRulesDelete(ctx context.Context, d *schema.ResourceData, me
 		FirewallRules: nil,
 	}
 
-	if _, err := client.WaitForServerState(ctx, &request.WaitForServerStateRequest{
-		UUID:           opts.ServerUUID,
-		UndesiredState: upcloud.ServerStateMaintenance,
-		Timeout:        time.Minute * 5,
-	}); err != nil {
+	if _, err := utils.WaitForServerToSettle(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutDelete)); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := client.CreateFirewallRules(ctx, opts); err != nil {
+	if err := utils.RetryWhileServerBusy(ctx, meta, opts.ServerUUID, d.Timeout(schema.TimeoutDelete), func() error {
+		return client.CreateFirewallRules(ctx, opts)
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
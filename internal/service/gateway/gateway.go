@@ -7,19 +7,21 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const (
 	nameDescription             = "Gateway name. Needs to be unique within the account."
-	zoneDescription             = "Zone in which the gateway will be hosted, e.g. `de-fra1`."
+	zoneDescription             = "Zone in which the gateway will be hosted, e.g. `de-fra1`. Defaults to the `zone` of the provider."
 	featuresDescription         = "Features enabled for the gateway."
 	routerDescription           = "Attached Router from where traffic is routed towards the network gateway service."
 	routerIDDescription         = "ID of the router attached to the gateway."
@@ -40,6 +42,11 @@ func ResourceGateway() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Description:      nameDescription,
@@ -50,7 +57,8 @@ func ResourceGateway() *schema.Resource {
 			"zone": {
 				Description: zoneDescription,
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"features": {
@@ -80,7 +88,8 @@ func ResourceGateway() *schema.Resource {
 					},
 				},
 			},
-			"labels": utils.LabelsSchema("network gateway"),
+			"labels":     utils.LabelsSchema("network gateway"),
+			"labels_all": utils.LabelsAllSchema("network gateway"),
 			"configured_status": {
 				Description:      configuredStatusDescription,
 				Type:             schema.TypeString,
@@ -113,11 +122,15 @@ func ResourceGateway() *schema.Resource {
 				},
 			},
 		},
+		CustomizeDiff: customdiff.All(
+			utils.MergeDefaultLabels,
+			utils.SetDefaultZone,
+		),
 	}
 }
 
 func resourceGatewayCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	features := []upcloud.GatewayFeature{}
 	for _, i := range d.Get("features").(*schema.Set).List() {
@@ -131,7 +144,7 @@ func resourceGatewayCreate(ctx context.Context, d *schema.ResourceData, meta int
 		Routers: []request.GatewayRouter{
 			{UUID: d.Get("router.0.id").(string)},
 		},
-		Labels:           utils.LabelsMapToSlice(d.Get("labels").(map[string]interface{})),
+		Labels:           utils.LabelsMapToSlice(utils.LabelsWithDefaults(d, meta)),
 		ConfiguredStatus: upcloud.GatewayConfiguredStatus(d.Get("configured_status").(string)),
 	}
 
@@ -147,7 +160,7 @@ func resourceGatewayCreate(ctx context.Context, d *schema.ResourceData, meta int
 		return diag.FromErr(err)
 	}
 
-	diags = append(diags, setGatewayResourceData(d, gw)...)
+	diags = append(diags, setGatewayResourceData(d, meta, gw)...)
 
 	// No error, log a success message
 	if len(diags) == 0 {
@@ -158,13 +171,13 @@ func resourceGatewayCreate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceGatewayRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	gw, err := svc.GetGateway(ctx, &request.GetGatewayRequest{UUID: d.Id()})
 	if err != nil {
 		return utils.HandleResourceError(d.Get("name").(string), d, err)
 	}
 
-	return setGatewayResourceData(d, gw)
+	return setGatewayResourceData(d, meta, gw)
 }
 
 func resourceGatewayUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -180,21 +193,21 @@ func resourceGatewayUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		req.ConfiguredStatus = upcloud.GatewayConfiguredStatus(d.Get("configured_status").(string))
 	}
 
-	if d.HasChange("labels") {
-		req.Labels = utils.LabelsMapToSlice(d.Get("labels").(map[string]interface{}))
+	if d.HasChanges("labels", "labels_all") {
+		req.Labels = utils.LabelsMapToSlice(utils.LabelsWithDefaults(d, meta))
 	}
 
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	gw, err := svc.ModifyGateway(ctx, &req)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return setGatewayResourceData(d, gw)
+	return setGatewayResourceData(d, meta, gw)
 }
 
 func resourceGatewayDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	if err := svc.DeleteGateway(ctx, &request.DeleteGatewayRequest{UUID: d.Id()}); err != nil {
 		return diag.FromErr(err)
 	}
@@ -209,7 +222,7 @@ func resourceGatewayDelete(ctx context.Context, d *schema.ResourceData, meta int
 	return diags
 }
 
-func setGatewayResourceData(d *schema.ResourceData, gw *upcloud.Gateway) (diags diag.Diagnostics) {
+func setGatewayResourceData(d *schema.ResourceData, meta interface{}, gw *upcloud.Gateway) (diags diag.Diagnostics) {
 	if err := d.Set("name", gw.Name); err != nil {
 		return diag.FromErr(err)
 	}
@@ -226,7 +239,7 @@ func setGatewayResourceData(d *schema.ResourceData, gw *upcloud.Gateway) (diags
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("labels", utils.LabelsSliceToMap(gw.Labels)); err != nil {
+	if err := utils.SetLabels(d, meta, utils.LabelsSliceToMap(gw.Labels)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -254,12 +267,10 @@ func setGatewayResourceData(d *schema.ResourceData, gw *upcloud.Gateway) (diags
 }
 
 func waitForGatewayToBeRunning(ctx context.Context, svc *service.Service, id string) (*upcloud.Gateway, error) {
-	const maxRetries int = 500
-
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, fmt.Errorf("timeout reached while waiting for network gateway to be running: %w", ctx.Err())
 		default:
 			gw, err := svc.GetGateway(ctx, &request.GetGatewayRequest{UUID: id})
 			if err != nil {
@@ -273,17 +284,13 @@ func waitForGatewayToBeRunning(ctx context.Context, svc *service.Service, id str
 		}
 		time.Sleep(5 * time.Second)
 	}
-
-	return nil, fmt.Errorf("max retries (%d)reached while waiting for network gateway to be running", maxRetries)
 }
 
 func waitForGatewayToBeDeleted(ctx context.Context, svc *service.Service, id string) error {
-	const maxRetries int = 500
-
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fmt.Errorf("timeout reached while waiting for network gateway to be deleted: %w", ctx.Err())
 		default:
 			c, err := svc.GetGateway(ctx, &request.GetGatewayRequest{UUID: id})
 			if err != nil {
@@ -298,8 +305,6 @@ func waitForGatewayToBeDeleted(ctx context.Context, svc *service.Service, id str
 		}
 		time.Sleep(5 * time.Second)
 	}
-
-	return fmt.Errorf("max retries (%d)reached while waiting for network gateway to be deleted", maxRetries)
 }
 
 var validateName = validation.ToDiagFunc(validation.All(
@@ -3,10 +3,10 @@ package ip
 import (
 	"context"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -49,17 +49,32 @@ func ResourceFloatingIPAddress() *schema.Resource {
 				ValidateFunc: validation.IsMACAddress,
 			},
 			"zone": {
-				Description: "Zone of address, required when assigning a detached floating IP address, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
+				Description: "Zone of address, required when assigning a detached floating IP address, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Detached floating IP addresses default to the `zone` of the provider.",
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
 			},
 		},
+		CustomizeDiff: setDefaultZone,
 	}
 }
 
+// setDefaultZone uses the default zone of the provider for new detached floating IP addresses that do not define zone.
+// Zone of an attached address is determined by the server it is attached to.
+func setDefaultZone(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" || !d.NewValueKnown("mac_address") || d.Get("mac_address").(string) != "" {
+		return nil
+	}
+
+	if m, ok := meta.(*config.Meta); !ok || m.DefaultZone == "" {
+		return nil
+	}
+
+	return utils.SetDefaultZone(ctx, d, meta)
+}
+
 func resourceFloatingIPAddressCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	assignIPAddressRequest := &request.AssignIPAddressRequest{
 		Floating: upcloud.True,
@@ -78,6 +93,12 @@ func resourceFloatingIPAddressCreate(ctx context.Context, d *schema.ResourceData
 		assignIPAddressRequest.Zone = zone.(string)
 	}
 
+	unlock, err := lockServersByMAC(ctx, meta, assignIPAddressRequest.MAC)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer unlock()
+
 	ipAddress, err := client.AssignIPAddress(ctx, assignIPAddressRequest)
 	if err != nil {
 		return diag.FromErr(err)
@@ -93,7 +114,7 @@ func resourceFloatingIPAddressCreate(ctx context.Context, d *schema.ResourceData
 }
 
 func resourceFloatingIPAddressRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -134,18 +155,24 @@ func resourceFloatingIPAddressRead(ctx context.Context, d *schema.ResourceData,
 }
 
 func resourceFloatingIPAddressUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	modifyIPAddressRequest := &request.ModifyIPAddressRequest{
 		IPAddress: d.Id(),
 	}
 
+	oldMAC, newMAC := d.GetChange("mac_address")
 	if d.HasChange("mac_address") {
-		_, newMAC := d.GetChange("mac_address")
 		modifyIPAddressRequest.MAC = newMAC.(string)
 	}
 
-	_, err := client.ModifyIPAddress(ctx, modifyIPAddressRequest)
+	unlock, err := lockServersByMAC(ctx, meta, oldMAC.(string), newMAC.(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer unlock()
+
+	_, err = client.ModifyIPAddress(ctx, modifyIPAddressRequest)
 	if err != nil {
 		diag.FromErr(err)
 	}
@@ -154,17 +181,23 @@ func resourceFloatingIPAddressUpdate(ctx context.Context, d *schema.ResourceData
 }
 
 func resourceFloatingIPAddressDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
-	if _, ok := d.GetOk("mac_address"); ok {
+	if mac, ok := d.GetOk("mac_address"); ok {
+		unlock, err := lockServersByMAC(ctx, meta, mac.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		defer unlock()
+
 		modifyIPAddressRequest := &request.ModifyIPAddressRequest{
 			IPAddress: d.Id(),
 			MAC:       "",
 		}
 
-		_, err := client.ModifyIPAddress(ctx, modifyIPAddressRequest)
+		_, err = client.ModifyIPAddress(ctx, modifyIPAddressRequest)
 		if err != nil {
 			diag.FromErr(err)
 		}
@@ -183,3 +216,31 @@ func resourceFloatingIPAddressDelete(ctx context.Context, d *schema.ResourceData
 
 	return diags
 }
+
+// lockServersByMAC acquires the locks of the servers that have network interfaces with the given MAC addresses.
+// Returned function releases the locks.
+func lockServersByMAC(ctx context.Context, meta interface{}, macs ...string) (func(), error) {
+	lookup := make(map[string]bool)
+	for _, mac := range macs {
+		if mac != "" {
+			lookup[mac] = true
+		}
+	}
+	if len(lookup) == 0 {
+		return func() {}, nil
+	}
+
+	addresses, err := meta.(*config.Meta).Service.GetIPAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var uuids []string
+	for _, address := range addresses.IPAddresses {
+		if lookup[address.MAC] && address.ServerUUID != "" {
+			uuids = append(uuids, address.ServerUUID)
+		}
+	}
+
+	return utils.LockServers(ctx, meta, uuids...), nil
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
@@ -30,7 +31,7 @@ const (
 	networkCIDRDescription          = "Network CIDR for the given network. Computed automatically."
 	nodeGroupNamesDescription       = "Names of the node groups configured to cluster"
 	stateDescription                = "Operational state of the cluster."
-	zoneDescription                 = "Zone in which the Kubernetes cluster will be hosted, e.g. `de-fra1`. You can list available zones with `upctl zone list`."
+	zoneDescription                 = "Zone in which the Kubernetes cluster will be hosted, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider."
 
 	cleanupWaitTimeSeconds = 240
 	maxResourceNameLength  = 63
@@ -46,6 +47,11 @@ func ResourceCluster() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"control_plane_ip_filter": {
 				Description: controlPlaneIPFilterDescription,
@@ -103,7 +109,8 @@ func ResourceCluster() *schema.Resource {
 			"zone": {
 				Description: zoneDescription,
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"version": {
@@ -114,11 +121,12 @@ func ResourceCluster() *schema.Resource {
 				Computed:    true,
 			},
 		},
+		CustomizeDiff: utils.SetDefaultZone,
 	}
 }
 
 func resourceClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := &request.CreateKubernetesClusterRequest{
 		Name:              d.Get("name").(string),
@@ -144,7 +152,7 @@ func resourceClusterCreate(ctx context.Context, d *schema.ResourceData, meta int
 
 	c, err = svc.WaitForKubernetesClusterState(ctx, &request.WaitForKubernetesClusterStateRequest{
 		DesiredState: upcloud.KubernetesClusterStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 		UUID:         c.UUID,
 	})
 	if err != nil {
@@ -162,7 +170,7 @@ func resourceClusterCreate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	cluster, err := svc.GetKubernetesCluster(ctx, &request.GetKubernetesClusterRequest{UUID: d.Id()})
 	if err != nil {
 		return utils.HandleResourceError(d.Get("name").(string), d, err)
@@ -172,7 +180,7 @@ func resourceClusterRead(ctx context.Context, d *schema.ResourceData, meta inter
 }
 
 func resourceClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := &request.ModifyKubernetesClusterRequest{
 		ClusterUUID: d.Id(),
@@ -191,7 +199,7 @@ func resourceClusterUpdate(ctx context.Context, d *schema.ResourceData, meta int
 
 	c, err = svc.WaitForKubernetesClusterState(ctx, &request.WaitForKubernetesClusterStateRequest{
 		DesiredState: upcloud.KubernetesClusterStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutUpdate),
 		UUID:         c.UUID,
 	})
 	if err != nil {
@@ -202,7 +210,7 @@ func resourceClusterUpdate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	if err := svc.DeleteKubernetesCluster(ctx, &request.DeleteKubernetesClusterRequest{UUID: d.Id()}); err != nil {
 		return diag.FromErr(err)
 	}
@@ -273,12 +281,10 @@ func setClusterResourceData(d *schema.ResourceData, c *upcloud.KubernetesCluster
 }
 
 func waitForClusterToBeDeleted(ctx context.Context, svc *service.Service, id string) error {
-	const maxRetries int = 100
-
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fmt.Errorf("timeout reached while waiting for cluster to be deleted: %w", ctx.Err())
 		default:
 			c, err := svc.GetKubernetesCluster(ctx, &request.GetKubernetesClusterRequest{UUID: id})
 			if err != nil {
@@ -293,8 +299,6 @@ func waitForClusterToBeDeleted(ctx context.Context, svc *service.Service, id str
 		}
 		time.Sleep(5 * time.Second)
 	}
-
-	return fmt.Errorf("max retries (%d)reached while waiting for cluster to be deleted", maxRetries)
 }
 
 var validateResourceName = validation.ToDiagFunc(func(i interface{}, s string) (warns []string, errs []error) {
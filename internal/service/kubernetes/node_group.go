@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
@@ -28,6 +29,11 @@ func ResourceNodeGroup() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"cluster": {
 				Description: idDescription,
@@ -49,7 +55,7 @@ func ResourceNodeGroup() *schema.Resource {
 				ForceNew:         true,
 			},
 			"plan": {
-				Description: "The server plan used for the node group. You can list available plans with `upctl server plans`",
+				Description: "The server plan used for the node group. You can list available plans with `upctl server plans` or select a plan with the `upcloud_server_plans` data source",
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
@@ -150,7 +156,7 @@ func ResourceNodeGroup() *schema.Resource {
 }
 
 func resourceNodeGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := request.KubernetesNodeGroup{
 		Count:                d.Get("node_count").(int),
@@ -211,7 +217,7 @@ func resourceNodeGroupCreate(ctx context.Context, d *schema.ResourceData, meta i
 
 	ng, err = svc.WaitForKubernetesNodeGroupState(ctx, &request.WaitForKubernetesNodeGroupStateRequest{
 		DesiredState: upcloud.KubernetesNodeGroupStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 		ClusterUUID:  clusterID,
 		Name:         ng.Name,
 	})
@@ -223,7 +229,7 @@ func resourceNodeGroupCreate(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceNodeGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var clusterID, name string
 	if err := unmarshalID(d.Id(), &clusterID, &name); err != nil {
 		return diag.FromErr(err)
@@ -243,7 +249,7 @@ func resourceNodeGroupUpdate(ctx context.Context, d *schema.ResourceData, meta i
 	if !d.HasChange("node_count") {
 		return nil
 	}
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var clusterID, name string
 	if err := unmarshalID(d.Id(), &clusterID, &name); err != nil {
 		return diag.FromErr(err)
@@ -261,7 +267,7 @@ func resourceNodeGroupUpdate(ctx context.Context, d *schema.ResourceData, meta i
 
 	ng, err = svc.WaitForKubernetesNodeGroupState(ctx, &request.WaitForKubernetesNodeGroupStateRequest{
 		DesiredState: upcloud.KubernetesNodeGroupStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutUpdate),
 		ClusterUUID:  clusterID,
 		Name:         ng.Name,
 	})
@@ -273,7 +279,7 @@ func resourceNodeGroupUpdate(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceNodeGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var clusterID, name string
 	if err := unmarshalID(d.Id(), &clusterID, &name); err != nil {
 		return diag.FromErr(err)
@@ -354,12 +360,10 @@ func setNodeGroupResourceData(d *schema.ResourceData, clusterID string, ng *upcl
 }
 
 func waitForNodeGroupToBeDeleted(ctx context.Context, svc *service.Service, clusterID, name string) error {
-	const maxRetries int = 100
-
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fmt.Errorf("timeout reached while waiting for node group to be deleted: %w", ctx.Err())
 		default:
 			c, err := svc.GetKubernetesNodeGroup(ctx, &request.GetKubernetesNodeGroupRequest{
 				ClusterUUID: clusterID,
@@ -377,6 +381,4 @@ func waitForNodeGroupToBeDeleted(ctx context.Context, svc *service.Service, clus
 		}
 		time.Sleep(5 * time.Second)
 	}
-
-	return fmt.Errorf("max retries (%d)reached while waiting for node group to be deleted", maxRetries)
 }
@@ -3,11 +3,11 @@ package loadbalancer
 import (
 	"context"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/validator"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -135,7 +135,7 @@ func ResourceDynamicBackendMember() *schema.Resource {
 
 func resourceBackendMemberCreateFunc(memberType upcloud.LoadBalancerBackendMemberType) schema.CreateContextFunc {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-		svc := meta.(*service.Service)
+		svc := meta.(*config.Meta).Service
 		var serviceID, beName string
 		if err := unmarshalID(d.Get("backend").(string), &serviceID, &beName); err != nil {
 			return diag.FromErr(err)
@@ -169,7 +169,7 @@ func resourceBackendMemberCreateFunc(memberType upcloud.LoadBalancerBackendMembe
 }
 
 func resourceBackendMemberRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var serviceID, beName, name string
 	if err := unmarshalID(d.Id(), &serviceID, &beName, &name); err != nil {
 		return diag.FromErr(err)
@@ -197,7 +197,7 @@ func resourceBackendMemberRead(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceBackendMemberUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var serviceID, beName, name string
 	if err := unmarshalID(d.Id(), &serviceID, &beName, &name); err != nil {
 		return diag.FromErr(err)
@@ -230,7 +230,7 @@ func resourceBackendMemberUpdate(ctx context.Context, d *schema.ResourceData, me
 }
 
 func resourceBackendMemberDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	var serviceID, beName, name string
 	if err := unmarshalID(d.Id(), &serviceID, &beName, &name); err != nil {
 		return diag.FromErr(err)
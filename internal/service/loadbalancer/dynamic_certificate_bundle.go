@@ -4,10 +4,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -69,7 +69,7 @@ func ResourceDynamicCertificateBundle() *schema.Resource {
 }
 
 func resourceDynamicCertificateBundleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	hostnames := make([]string, 0)
 	for _, h := range d.Get("hostnames").([]interface{}) {
 		hostnames = append(hostnames, h.(string))
@@ -95,7 +95,7 @@ func resourceDynamicCertificateBundleCreate(ctx context.Context, d *schema.Resou
 }
 
 func resourceDynamicCertificateBundleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	b, err := svc.GetLoadBalancerCertificateBundle(ctx, &request.GetLoadBalancerCertificateBundleRequest{
 		UUID: d.Id(),
 	})
@@ -111,7 +111,7 @@ func resourceDynamicCertificateBundleRead(ctx context.Context, d *schema.Resourc
 }
 
 func resourceDynamicCertificateBundleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	hostnames := make([]string, 0)
 	for _, h := range d.Get("hostnames").([]interface{}) {
 		hostnames = append(hostnames, h.(string))
@@ -2,12 +2,12 @@ package loadbalancer
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
@@ -29,6 +29,11 @@ func ResourceLoadBalancer() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Description:      "The name of the service must be unique within customer account.",
@@ -42,9 +47,10 @@ func ResourceLoadBalancer() *schema.Resource {
 				Required:    true,
 			},
 			"zone": {
-				Description: "Zone in which the service will be hosted, e.g. `fi-hel1`. You can list available zones with `upctl zone list`.",
+				Description: "Zone in which the service will be hosted, e.g. `fi-hel1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 			},
 			"networks": {
 				ExactlyOneOf: []string{"network"},
@@ -119,11 +125,16 @@ func ResourceLoadBalancer() *schema.Resource {
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
-			"labels": utils.LabelsSchema("load balancer"),
+			"labels":     utils.LabelsSchema("load balancer"),
+			"labels_all": utils.LabelsAllSchema("load balancer"),
 		},
-		CustomizeDiff: customdiff.ForceNewIfChange("networks.#", func(ctx context.Context, old, new, meta interface{}) bool {
-			return new.(int) != old.(int)
-		}),
+		CustomizeDiff: customdiff.All(
+			customdiff.ForceNewIfChange("networks.#", func(ctx context.Context, old, new, meta interface{}) bool {
+				return new.(int) != old.(int)
+			}),
+			utils.MergeDefaultLabels,
+			utils.SetDefaultZone,
+		),
 	}
 }
 
@@ -231,7 +242,7 @@ func loadBalancerNodeNetworkSchema() map[string]*schema.Schema {
 }
 
 func resourceLoadBalancerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	networks, err := loadBalancerNetworksFromResourceData(d)
 	if err != nil {
 		return diag.FromErr(err)
@@ -246,7 +257,7 @@ func resourceLoadBalancerCreate(ctx context.Context, d *schema.ResourceData, met
 		Backends:         []request.LoadBalancerBackend{},
 		Resolvers:        []request.LoadBalancerResolver{},
 		Networks:         networks,
-		Labels:           utils.LabelsMapToSlice(d.Get("labels").(map[string]interface{})),
+		Labels:           utils.LabelsMapToSlice(utils.LabelsWithDefaults(d, meta)),
 	}
 	lb, err := svc.CreateLoadBalancer(ctx, req)
 	if err != nil {
@@ -255,7 +266,7 @@ func resourceLoadBalancerCreate(ctx context.Context, d *schema.ResourceData, met
 
 	d.SetId(lb.UUID)
 
-	if diags = setLoadBalancerResourceData(d, lb); len(diags) > 0 {
+	if diags = setLoadBalancerResourceData(d, meta, lb); len(diags) > 0 {
 		return diags
 	}
 
@@ -265,13 +276,13 @@ func resourceLoadBalancerCreate(ctx context.Context, d *schema.ResourceData, met
 
 func resourceLoadBalancerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
 	var err error
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	lb, err := svc.GetLoadBalancer(ctx, &request.GetLoadBalancerRequest{UUID: d.Id()})
 	if err != nil {
 		return utils.HandleResourceError(d.Get("name").(string), d, err)
 	}
 
-	if diags = setLoadBalancerResourceData(d, lb); len(diags) > 0 {
+	if diags = setLoadBalancerResourceData(d, meta, lb); len(diags) > 0 {
 		return diags
 	}
 
@@ -279,7 +290,7 @@ func resourceLoadBalancerRead(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceLoadBalancerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	// handle network renaming before modifying load balancer so that new network names are present in `lb` before setting state
 	if diags = resourceLoadBalancerNetworkUpdate(ctx, d, svc); len(diags) > 0 {
@@ -293,8 +304,8 @@ func resourceLoadBalancerUpdate(ctx context.Context, d *schema.ResourceData, met
 		ConfiguredStatus: d.Get("configured_status").(string),
 	}
 
-	if d.HasChange("labels") {
-		labels := utils.LabelsMapToSlice(d.Get("labels").(map[string]interface{}))
+	if d.HasChanges("labels", "labels_all") {
+		labels := utils.LabelsMapToSlice(utils.LabelsWithDefaults(d, meta))
 		req.Labels = &labels
 	}
 
@@ -303,7 +314,7 @@ func resourceLoadBalancerUpdate(ctx context.Context, d *schema.ResourceData, met
 		return utils.HandleResourceError(d.Get("name").(string), d, err)
 	}
 
-	if diags = setLoadBalancerResourceData(d, lb); len(diags) > 0 {
+	if diags = setLoadBalancerResourceData(d, meta, lb); len(diags) > 0 {
 		return diags
 	}
 
@@ -344,7 +355,7 @@ func resourceLoadBalancerNetworkUpdate(ctx context.Context, d *schema.ResourceDa
 }
 
 func resourceLoadBalancerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	if err := svc.DeleteLoadBalancer(ctx, &request.DeleteLoadBalancerRequest{UUID: d.Id()}); err != nil {
 		return diag.FromErr(err)
 	}
@@ -354,7 +365,7 @@ func resourceLoadBalancerDelete(ctx context.Context, d *schema.ResourceData, met
 	return diag.FromErr(waitLoadBalancerToShutdown(ctx, svc, d.Id()))
 }
 
-func setLoadBalancerResourceData(d *schema.ResourceData, lb *upcloud.LoadBalancer) (diags diag.Diagnostics) {
+func setLoadBalancerResourceData(d *schema.ResourceData, meta interface{}, lb *upcloud.LoadBalancer) (diags diag.Diagnostics) {
 	if err := d.Set("name", lb.Name); err != nil {
 		return diag.FromErr(err)
 	}
@@ -379,7 +390,7 @@ func setLoadBalancerResourceData(d *schema.ResourceData, lb *upcloud.LoadBalance
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("labels", utils.LabelsSliceToMap(lb.Labels)); err != nil {
+	if err := utils.SetLabels(d, meta, utils.LabelsSliceToMap(lb.Labels)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -472,11 +483,10 @@ func setLoadBalancerNetworkResourceData(d *schema.ResourceData, lb *upcloud.Load
 }
 
 func waitLoadBalancerToShutdown(ctx context.Context, svc *service.Service, id string) error {
-	const maxRetries int = 100
-	for i := 0; i <= maxRetries; i++ {
+	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fmt.Errorf("timeout reached while waiting for load balancer instance to shutdown: %w", ctx.Err())
 		default:
 			lb, err := svc.GetLoadBalancer(ctx, &request.GetLoadBalancerRequest{UUID: id})
 			if err != nil {
@@ -489,7 +499,6 @@ func waitLoadBalancerToShutdown(ctx context.Context, svc *service.Service, id st
 		}
 		time.Sleep(5 * time.Second)
 	}
-	return errors.New("max retries reached while waiting for load balancer instance to shutdown")
 }
 
 func loadBalancerNetworksFromResourceData(d *schema.ResourceData) ([]request.LoadBalancerNetwork, error) {
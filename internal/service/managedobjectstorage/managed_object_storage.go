@@ -6,18 +6,19 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourceManagedObjectStorage() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "This resource represents an UpCloud Managed Object Storage instance, which provides S3 compatible storage.",
 		CreateContext: resourceManagedObjectStorageCreate,
 		ReadContext:   resourceManagedObjectStorageRead,
@@ -26,6 +27,11 @@ func ResourceManagedObjectStorage() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"configured_status": {
 				Description: "Service status managed by the end user.",
@@ -44,13 +50,15 @@ func ResourceManagedObjectStorage() *schema.Resource {
 				Computed:    true,
 				Type:        schema.TypeString,
 			},
+			"deletion_protection": utils.DeletionProtectionSchema("managed object storage"),
 			"endpoint": {
 				Description: "Endpoints for accessing the Managed Object Storage service.",
 				Computed:    true,
 				Type:        schema.TypeSet,
 				Elem:        schemaEndpoint(),
 			},
-			"labels": utils.LabelsSchema("managed object storage"),
+			"labels":     utils.LabelsSchema("managed object storage"),
+			"labels_all": utils.LabelsAllSchema("managed object storage"),
 			"network": {
 				Description: "Attached networks from where object storage can be used. Private networks must reside in object storage region. To gain access from multiple private networks that might reside in different zones, create the networks and a corresponding router for each network.",
 				Optional:    true,
@@ -82,6 +90,11 @@ func ResourceManagedObjectStorage() *schema.Resource {
 			},
 		},
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.MergeDefaultLabels,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func schemaEndpoint() *schema.Resource {
@@ -149,15 +162,15 @@ func schemaNetwork() *schema.Resource {
 }
 
 func resourceManagedObjectStorageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := &request.CreateManagedObjectStorageRequest{
 		ConfiguredStatus: upcloud.ManagedObjectStorageConfiguredStatus(d.Get("configured_status").(string)),
 		Region:           d.Get("region").(string),
 	}
 
-	if v, ok := d.GetOk("labels"); ok {
-		req.Labels = utils.LabelsMapToSlice(v.(map[string]interface{}))
+	if labels := utils.LabelsWithDefaults(d, meta); len(labels) > 0 {
+		req.Labels = utils.LabelsMapToSlice(labels)
 	}
 
 	networks, err := networksFromResourceData(d)
@@ -187,7 +200,7 @@ func resourceManagedObjectStorageCreate(ctx context.Context, d *schema.ResourceD
 
 	waitReq := &request.WaitForManagedObjectStorageOperationalStateRequest{
 		DesiredState: upcloud.ManagedObjectStorageOperationalStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 		UUID:         storage.UUID,
 	}
 
@@ -200,23 +213,23 @@ func resourceManagedObjectStorageCreate(ctx context.Context, d *schema.ResourceD
 		return diag.FromErr(err)
 	}
 
-	return append(diags, setManagedObjectStorageData(d, storage)...)
+	return append(diags, setManagedObjectStorageData(d, meta, storage)...)
 }
 
 func resourceManagedObjectStorageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var err error
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	storage, err := svc.GetManagedObjectStorage(ctx, &request.GetManagedObjectStorageRequest{UUID: d.Id()})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return setManagedObjectStorageData(d, storage)
+	return setManagedObjectStorageData(d, meta, storage)
 }
 
 func resourceManagedObjectStorageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := &request.ModifyManagedObjectStorageRequest{
 		UUID: d.Id(),
@@ -227,8 +240,8 @@ func resourceManagedObjectStorageUpdate(ctx context.Context, d *schema.ResourceD
 		req.ConfiguredStatus = &configuredStatus
 	}
 
-	if d.HasChange("labels") {
-		labels := utils.LabelsMapToSlice(d.Get("labels").(map[string]interface{}))
+	if d.HasChanges("labels", "labels_all") {
+		labels := utils.LabelsMapToSlice(utils.LabelsWithDefaults(d, meta))
 		req.Labels = &labels
 	}
 
@@ -259,7 +272,7 @@ func resourceManagedObjectStorageUpdate(ctx context.Context, d *schema.ResourceD
 
 	waitReq := &request.WaitForManagedObjectStorageOperationalStateRequest{
 		DesiredState: upcloud.ManagedObjectStorageOperationalStateRunning,
-		Timeout:      time.Minute * 20,
+		Timeout:      d.Timeout(schema.TimeoutUpdate),
 		UUID:         storage.UUID,
 	}
 
@@ -272,18 +285,22 @@ func resourceManagedObjectStorageUpdate(ctx context.Context, d *schema.ResourceD
 		return diag.FromErr(err)
 	}
 
-	return setManagedObjectStorageData(d, storage)
+	return setManagedObjectStorageData(d, meta, storage)
 }
 
 func resourceManagedObjectStorageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
+	svc := meta.(*config.Meta).Service
 	err := svc.DeleteManagedObjectStorage(ctx, &request.DeleteManagedObjectStorageRequest{UUID: d.Id()})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	err = svc.WaitForManagedObjectStorageDeletion(ctx, &request.WaitForManagedObjectStorageDeletionRequest{
-		Timeout: time.Minute * 20,
+		Timeout: d.Timeout(schema.TimeoutDelete),
 		UUID:    d.Id(),
 	})
 	if err != nil {
@@ -324,7 +341,7 @@ func networksFromResourceData(d *schema.ResourceData) ([]upcloud.ManagedObjectSt
 	return req, nil
 }
 
-func setManagedObjectStorageData(d *schema.ResourceData, storage *upcloud.ManagedObjectStorage) (diags diag.Diagnostics) {
+func setManagedObjectStorageData(d *schema.ResourceData, meta interface{}, storage *upcloud.ManagedObjectStorage) (diags diag.Diagnostics) {
 	if err := d.Set("configured_status", storage.ConfiguredStatus); err != nil {
 		return diag.FromErr(err)
 	}
@@ -344,6 +361,10 @@ func setManagedObjectStorageData(d *schema.ResourceData, storage *upcloud.Manage
 		return diag.FromErr(err)
 	}
 
+	if err := utils.SetLabels(d, meta, utils.LabelsSliceToMap(storage.Labels)); err != nil {
+		return diag.FromErr(err)
+	}
+
 	networks := make([]map[string]interface{}, 0)
 	for _, network := range storage.Networks {
 		networks = append(networks, map[string]interface{}{
@@ -6,9 +6,9 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -83,7 +83,7 @@ func ResourceManagedObjectStorageUserAccessKey() *schema.Resource {
 }
 
 func resourceManagedObjectStorageUserAccessKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	req := &request.CreateManagedObjectStorageUserAccessKeyRequest{
 		Username:    d.Get("username").(string),
@@ -103,7 +103,7 @@ func resourceManagedObjectStorageUserAccessKeyCreate(ctx context.Context, d *sch
 }
 
 func resourceManagedObjectStorageUserAccessKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	var serviceUUID, username, name string
 	if err := unmarshalID(d.Id(), &serviceUUID, &username, &name); err != nil {
@@ -127,7 +127,7 @@ func resourceManagedObjectStorageUserAccessKeyUpdate(ctx context.Context, d *sch
 		return nil
 	}
 
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	var serviceUUID, username, name string
 	if err := unmarshalID(d.Id(), &serviceUUID, &username, &name); err != nil {
@@ -150,7 +150,7 @@ func resourceManagedObjectStorageUserAccessKeyUpdate(ctx context.Context, d *sch
 }
 
 func resourceManagedObjectStorageUserAccessKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	var serviceUUID, username, name string
 	if err := unmarshalID(d.Id(), &serviceUUID, &username, &name); err != nil {
@@ -6,10 +6,10 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -113,8 +113,9 @@ func ResourceNetwork() *schema.Resource {
 			},
 			"zone": {
 				Type:        schema.TypeString,
-				Description: "The zone the network is in, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
-				Required:    true,
+				Description: "The zone the network is in, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"router": {
@@ -123,11 +124,12 @@ func ResourceNetwork() *schema.Resource {
 				Optional:    true,
 			},
 		},
+		CustomizeDiff: utils.SetDefaultZone,
 	}
 }
 
 func resourceNetworkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := request.CreateNetworkRequest{}
 	if v := d.Get("name"); v != nil {
@@ -176,7 +178,7 @@ func resourceNetworkCreate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := request.GetNetworkDetailsRequest{
 		UUID: d.Id(),
@@ -221,7 +223,7 @@ func resourceNetworkRead(ctx context.Context, d *schema.ResourceData, meta inter
 }
 
 func resourceNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := request.ModifyNetworkRequest{
 		UUID: d.Id(),
@@ -280,7 +282,7 @@ func resourceNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceNetworkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := request.DeleteNetworkRequest{
 		UUID: d.Id(),
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
@@ -75,9 +76,10 @@ This resource represents an UpCloud Object Storage instance, which provides S3 c
 				ValidateDiagFunc: createKeyValidationFunc("secret_key", secretKeyMinLength, secretKeyMaxLength),
 			},
 			"zone": {
-				Description: "The zone in which the object storage instance will be created, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
+				Description: "The zone in which the object storage instance will be created, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"name": {
@@ -123,6 +125,7 @@ This resource represents an UpCloud Object Storage instance, which provides S3 c
 				},
 			},
 		},
+		CustomizeDiff: utils.SetDefaultZone,
 	}
 }
 
@@ -132,7 +135,7 @@ func resourceObjectStorageCreate(ctx context.Context, d *schema.ResourceData, m
 		req   request.CreateObjectStorageRequest
 	)
 
-	client := m.(*service.Service)
+	client := m.(*config.Meta).Service
 
 	accessKey, _, err := getAccessKey(d)
 	if err != nil {
@@ -180,7 +183,7 @@ func resourceObjectStorageCreate(ctx context.Context, d *schema.ResourceData, m
 }
 
 func resourceObjectStorageRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*service.Service)
+	client := m.(*config.Meta).Service
 
 	uuid := d.Id()
 
@@ -195,7 +198,7 @@ func resourceObjectStorageRead(ctx context.Context, d *schema.ResourceData, m in
 }
 
 func resourceObjectStorageUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*service.Service)
+	client := m.(*config.Meta).Service
 
 	accessKey, _, err := getAccessKey(d)
 	if err != nil {
@@ -254,7 +257,7 @@ func resourceObjectStorageUpdate(ctx context.Context, d *schema.ResourceData, m
 }
 
 func resourceObjectStorageDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*service.Service)
+	client := m.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -77,7 +77,7 @@ func ResourceRouter() *schema.Resource {
 }
 
 func resourceRouterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := &request.CreateRouterRequest{
 		Name: d.Get("name").(string),
@@ -131,7 +131,7 @@ func resourceRouterCreate(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func resourceRouterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	opts := &request.GetRouterDetailsRequest{
 		UUID: d.Id(),
@@ -175,7 +175,7 @@ func resourceRouterRead(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceRouterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	req := &request.ModifyRouterRequest{
 		UUID: d.Id(),
@@ -210,7 +210,7 @@ func resourceRouterUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func resourceRouterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	router, err := client.GetRouterDetails(ctx, &request.GetRouterDetailsRequest{
 		UUID: d.Id(),
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const cloudConfigHeader = "#cloud-config"
+
+func cloudInitSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Block describing a cloud-init configuration. The configuration is rendered to a `#cloud-config` document and passed to the server as user data. " +
+			"Cloud-init reads the user data from the metadata service, so `metadata` must be set to `true`. " +
+			"Note that defining `users` replaces the default user of the template, unless one of the users is named `default`.",
+		Type:          schema.TypeList,
+		Optional:      true,
+		ForceNew:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"user_data"},
+		// Cloud-init configuration is passed to the server only when creating it
+		DiffSuppressFunc: suppressImportedServerDiff,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"users": {
+					Description: "Users to create on the server",
+					Type:        schema.TypeList,
+					Optional:    true,
+					ForceNew:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"name": {
+								Description: "The name of the user. Use `default` to keep the default user of the template.",
+								Type:        schema.TypeString,
+								Required:    true,
+								ForceNew:    true,
+							},
+							"groups": {
+								Description: "Additional groups of the user",
+								Type:        schema.TypeList,
+								Optional:    true,
+								ForceNew:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+							"sudo": {
+								Description: "Sudo rule of the user, e.g. `ALL=(ALL) NOPASSWD:ALL`",
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+							},
+							"shell": {
+								Description: "Login shell of the user, e.g. `/bin/bash`",
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+							},
+							"ssh_authorized_keys": {
+								Description: "SSH public keys that are allowed to log in as the user",
+								Type:        schema.TypeList,
+								Optional:    true,
+								ForceNew:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+						},
+					},
+				},
+				"packages": {
+					Description: "Packages to install on the first boot",
+					Type:        schema.TypeList,
+					Optional:    true,
+					ForceNew:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+				"write_files": {
+					Description: "Files to write on the first boot",
+					Type:        schema.TypeList,
+					Optional:    true,
+					ForceNew:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"path": {
+								Description: "Absolute path of the file",
+								Type:        schema.TypeString,
+								Required:    true,
+								ForceNew:    true,
+							},
+							"content": {
+								Description: "Content of the file",
+								Type:        schema.TypeString,
+								Required:    true,
+								ForceNew:    true,
+							},
+							"permissions": {
+								Description: "Permissions of the file in octal format, e.g. `0644`",
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+							},
+							"owner": {
+								Description: "Owner of the file in `user:group` format",
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+							},
+						},
+					},
+				},
+				"runcmd": {
+					Description: "Commands to run on the first boot. The commands are run with a shell after the packages have been installed and the files have been written.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					ForceNew:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+type cloudConfigUser struct {
+	Name              string   `yaml:"name"`
+	Groups            []string `yaml:"groups,omitempty"`
+	Sudo              string   `yaml:"sudo,omitempty"`
+	Shell             string   `yaml:"shell,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+type cloudConfigFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+}
+
+type cloudConfig struct {
+	Users      []interface{}     `yaml:"users,omitempty"`
+	Packages   []string          `yaml:"packages,omitempty"`
+	WriteFiles []cloudConfigFile `yaml:"write_files,omitempty"`
+	RunCmd     []string          `yaml:"runcmd,omitempty"`
+}
+
+// renderCloudConfig renders the cloud_init block to a cloud-config document.
+func renderCloudConfig(v map[string]interface{}) (string, error) {
+	var cfg cloudConfig
+	for _, u := range v["users"].([]interface{}) {
+		u := u.(map[string]interface{})
+		user := cloudConfigUser{
+			Name:              u["name"].(string),
+			Groups:            expandStringList(u["groups"]),
+			Sudo:              u["sudo"].(string),
+			Shell:             u["shell"].(string),
+			SSHAuthorizedKeys: expandStringList(u["ssh_authorized_keys"]),
+		}
+		// The default user of the template is referred to with plain `default` string
+		if user.Name == "default" && len(user.Groups) == 0 && user.Sudo == "" && user.Shell == "" && len(user.SSHAuthorizedKeys) == 0 {
+			cfg.Users = append(cfg.Users, user.Name)
+			continue
+		}
+		cfg.Users = append(cfg.Users, user)
+	}
+	cfg.Packages = expandStringList(v["packages"])
+	for _, f := range v["write_files"].([]interface{}) {
+		f := f.(map[string]interface{})
+		cfg.WriteFiles = append(cfg.WriteFiles, cloudConfigFile{
+			Path:        f["path"].(string),
+			Content:     f["content"].(string),
+			Permissions: f["permissions"].(string),
+			Owner:       f["owner"].(string),
+		})
+	}
+	cfg.RunCmd = expandStringList(v["runcmd"])
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return cloudConfigHeader + "\n" + string(out), nil
+}
+
+// validateUserData validates user_data that is either a URL, a script or a cloud-config document. Cloud-config
+// documents are parsed, so that syntax errors are reported when planning instead of when the server boots.
+func validateUserData(v interface{}, path cty.Path) diag.Diagnostics {
+	userData := v.(string)
+	switch {
+	case isUserDataURL(userData):
+		return nil
+	case strings.HasPrefix(userData, cloudConfigHeader):
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(userData), &doc); err != nil {
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Invalid cloud-config document",
+				Detail:        fmt.Sprintf("user_data starts with %s but could not be parsed: %s", cloudConfigHeader, err),
+				AttributePath: path,
+			}}
+		}
+		if _, ok := doc.(map[string]interface{}); doc != nil && !ok {
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Invalid cloud-config document",
+				Detail:        "cloud-config document must be a YAML mapping",
+				AttributePath: path,
+			}}
+		}
+		return nil
+	case strings.HasPrefix(userData, "#!"):
+		return nil
+	default:
+		return diag.Diagnostics{{
+			Severity:      diag.Warning,
+			Summary:       "Unrecognized user_data",
+			Detail:        fmt.Sprintf("user_data is neither a URL, a script starting with #! nor a document starting with %s", cloudConfigHeader),
+			AttributePath: path,
+		}}
+	}
+}
+
+func isUserDataURL(userData string) bool {
+	if strings.ContainsAny(userData, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(userData)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func expandStringList(v interface{}) []string {
+	var s []string
+	for _, i := range v.([]interface{}) {
+		s = append(s, i.(string))
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateUserData(t *testing.T) {
+	path := cty.GetAttrPath("user_data")
+
+	for _, valid := range []string{
+		"https://example.com/setup.sh",
+		"#!/bin/sh\necho hello",
+		"#cloud-config\npackages:\n  - nginx\n",
+		"#cloud-config\n",
+	} {
+		assert.Empty(t, validateUserData(valid, path), valid)
+	}
+
+	diags := validateUserData("#cloud-config\npackages:\n  - nginx\nruncmd: echo: hello\n", path)
+	require.Len(t, diags, 1)
+	assert.Equal(t, diag.Error, diags[0].Severity)
+	assert.Contains(t, diags[0].Detail, "line 4")
+
+	diags = validateUserData("#cloud-config\n- nginx\n", path)
+	require.Len(t, diags, 1)
+	assert.Equal(t, diag.Error, diags[0].Severity)
+
+	diags = validateUserData("echo hello", path)
+	require.Len(t, diags, 1)
+	assert.Equal(t, diag.Warning, diags[0].Severity)
+}
+
+func TestRenderCloudConfig(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ResourceServer().Schema, map[string]interface{}{
+		"cloud_init": []interface{}{map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"name": "default"},
+				map[string]interface{}{
+					"name":                "deploy",
+					"groups":              []interface{}{"sudo", "docker"},
+					"shell":               "/bin/bash",
+					"ssh_authorized_keys": []interface{}{"ssh-ed25519 AAAA"},
+				},
+			},
+			"packages": []interface{}{"nginx"},
+			"write_files": []interface{}{map[string]interface{}{
+				"path":        "/etc/motd",
+				"content":     "hello: world\n",
+				"permissions": "0644",
+			}},
+			"runcmd": []interface{}{"systemctl restart nginx"},
+		}},
+	})
+
+	userData, err := renderCloudConfig(d.Get("cloud_init.0").(map[string]interface{}))
+	require.NoError(t, err)
+	assert.Empty(t, validateUserData(userData, cty.GetAttrPath("user_data")))
+
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(userData), &doc))
+	assert.Equal(t, map[string]interface{}{
+		"users": []interface{}{
+			"default",
+			map[string]interface{}{
+				"name":                "deploy",
+				"groups":              []interface{}{"sudo", "docker"},
+				"shell":               "/bin/bash",
+				"ssh_authorized_keys": []interface{}{"ssh-ed25519 AAAA"},
+			},
+		},
+		"packages": []interface{}{"nginx"},
+		"write_files": []interface{}{map[string]interface{}{
+			"path":        "/etc/motd",
+			"content":     "hello: world\n",
+			"permissions": "0644",
+		}},
+		"runcmd": []interface{}{"systemctl restart nginx"},
+	}, doc)
+}
@@ -0,0 +1,753 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func DataSourceServer() *schema.Resource {
+	lookupArgs := []string{"id", "hostname", "title", "labels"}
+
+	return &schema.Resource{
+		Description: `
+Returns information about an existing server. The server is looked up by its UUID, hostname, title or labels.
+
+When more than one lookup argument is defined, the server must match all of them. The query must match exactly one server.`,
+		ReadContext: dataSourceServerRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Description:  "UUID of the server",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: lookupArgs,
+			},
+			"hostname": {
+				Description:  "Hostname of the server",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: lookupArgs,
+			},
+			"title": {
+				Description:  "Title of the server",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: lookupArgs,
+			},
+			"labels": {
+				Description:  "Labels of the server. When used as a lookup argument, the server must have all of the defined labels.",
+				Type:         schema.TypeMap,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: lookupArgs,
+			},
+			"zone": {
+				Description: "The zone in which the server resides, e.g. `de-fra1`. Limits the lookup to servers in this zone, if defined.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"plan": {
+				Description: "The pricing plan used for the server",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"cpu": {
+				Description: "The number of CPU for the server",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"mem": {
+				Description: "The size of memory for the server (in megabytes)",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"state": {
+				Description: "The current state of the server, e.g. `started`, `stopped` or `maintenance`",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"tags": {
+				Description: "The server related tags",
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+			},
+			"firewall": {
+				Description: "Are firewall rules active for the server",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"metadata": {
+				Description: "Is the metadata service active for the server",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"timezone": {
+				Description: "The timezone of the server",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"nic_model": {
+				Description: "The model of the server's network interfaces",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"video_model": {
+				Description: "The model of the server's video interface",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"host": {
+				Description: "The host the server is running on. Only available for private cloud hosts",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"server_group": {
+				Description: "The UUID of the server group the server belongs to",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"network_interface": {
+				Description: "The network interfaces of the server",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip_address_family": {
+							Description: "The IP address type of this interface (one of `IPv4` or `IPv6`).",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"ip_address": {
+							Description: "The assigned IP address.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"ip_address_floating": {
+							Description: "`true` if a floating IP address is attached.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"mac_address": {
+							Description: "The assigned MAC address.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"type": {
+							Description: "Network interface type. For private network interfaces, a network must be specified with an existing network id.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"network": {
+							Description: "The unique ID of a network to attach this network to.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"source_ip_filtering": {
+							Description: "`true` if source IP should be filtered.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"bootable": {
+							Description: "`true` if this interface should be used for network booting.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"storage_devices": {
+				Description: "The storage devices attached to the server",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": {
+							Description: "The device bus the storage is attached to, e.g. `virtio`",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"storage": {
+							Description: "The UUID of the storage",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"title": {
+							Description: "The title of the storage",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"size": {
+							Description: "The size of the storage in gigabytes",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"tier": {
+							Description: "The storage tier",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"type": {
+							Description: "The device type the storage is attached as, e.g. `disk` or `cdrom`",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	svc := meta.(*config.Meta).Service
+
+	server, err := findServer(ctx, svc, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if server == nil {
+		return diag.Errorf("query returned no results")
+	}
+
+	if err := setServerDataSourceData(d, server); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// findServer returns the server matching the lookup arguments, or nil if no server matches them.
+func findServer(ctx context.Context, svc *service.Service, d *schema.ResourceData) (*upcloud.ServerDetails, error) {
+	if id, ok := d.GetOk("id"); ok {
+		server, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: id.(string)})
+		if err != nil {
+			return nil, err
+		}
+		if !serverDetailsMatch(d, server) {
+			return nil, nil
+		}
+		return server, nil
+	}
+
+	servers, err := svc.GetServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *upcloud.ServerDetails
+	for _, s := range servers.Servers {
+		if !serverMatches(d, s) {
+			continue
+		}
+
+		// Labels are only included in the server details
+		server, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: s.UUID})
+		if err != nil {
+			return nil, err
+		}
+		if !serverDetailsMatch(d, server) {
+			continue
+		}
+
+		if match != nil {
+			return nil, errors.New("query returned more than one result")
+		}
+		match = server
+	}
+	return match, nil
+}
+
+// serverMatches reports whether the server matches the lookup arguments available in the server list.
+func serverMatches(d *schema.ResourceData, server upcloud.Server) bool {
+	if v, ok := d.GetOk("hostname"); ok && v.(string) != server.Hostname {
+		return false
+	}
+	if v, ok := d.GetOk("title"); ok && v.(string) != server.Title {
+		return false
+	}
+	if v, ok := d.GetOk("zone"); ok && v.(string) != server.Zone {
+		return false
+	}
+	return true
+}
+
+// serverDetailsMatch reports whether the server matches all lookup arguments.
+func serverDetailsMatch(d *schema.ResourceData, server *upcloud.ServerDetails) bool {
+	if !serverMatches(d, server.Server) {
+		return false
+	}
+
+	labels := utils.LabelsSliceToMap(server.Labels)
+	for k, v := range d.Get("labels").(map[string]interface{}) {
+		if value, ok := labels[k]; !ok || value != v.(string) {
+			return false
+		}
+	}
+	return true
+}
+
+func setServerDataSourceData(d *schema.ResourceData, server *upcloud.ServerDetails) error {
+	d.SetId(server.UUID)
+
+	storageDevices := make([]map[string]interface{}, 0, len(server.StorageDevices))
+	for _, device := range server.StorageDevices {
+		storageDevices = append(storageDevices, map[string]interface{}{
+			"address": utils.StorageAddressFormat(device.Address),
+			"storage": device.UUID,
+			"title":   device.Title,
+			"size":    device.Size,
+			"tier":    device.Tier,
+			"type":    device.Type,
+		})
+	}
+
+	data := map[string]interface{}{
+		"hostname":          server.Hostname,
+		"title":             server.Title,
+		"labels":            utils.LabelsSliceToMap(server.Labels),
+		"zone":              server.Zone,
+		"plan":              server.Plan,
+		"cpu":               server.CoreNumber,
+		"mem":               server.MemoryAmount,
+		"state":             server.State,
+		"tags":              []string(server.Tags),
+		"firewall":          strings.EqualFold(server.Firewall, "on"),
+		"metadata":          server.Metadata.Bool(),
+		"timezone":          server.Timezone,
+		"nic_model":         server.NICModel,
+		"video_model":       server.VideoModel,
+		"host":              server.Host,
+		"server_group":      server.ServerGroup,
+		"network_interface": flattenNetworkInterfaces(server.Networking.Interfaces),
+		"storage_devices":   storageDevices,
+	}
+	for k, v := range data {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DataSourceServers() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to list servers matching the given filters. All defined filters must match.",
+		ReadContext: dataSourceServersRead,
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Description: "If specified, this data source will return only servers from this zone",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"tags": {
+				Description: "If specified, this data source will return only servers that have all of these tags",
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+			},
+			"label_selector": {
+				Description: "If specified, this data source will return only servers whose labels match all of these requirements. " +
+					"Requirements are defined in `key=value`, `key!=value` or `key in (value1, value2)` format.",
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					ValidateDiagFunc: func(v interface{}, path cty.Path) diag.Diagnostics {
+						if _, err := utils.ParseLabelSelector([]string{v.(string)}); err != nil {
+							return diag.Diagnostics{{Severity: diag.Error, Summary: err.Error(), AttributePath: path}}
+						}
+						return nil
+					},
+				},
+				Optional: true,
+			},
+			"state": {
+				Description: "If specified, this data source will return only servers in this state, e.g. `started` or `stopped`",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"plan": {
+				Description: "If specified, this data source will return only servers using this plan",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"hostname_regex": {
+				Description:      "If specified, this data source will return only servers whose hostname matches this regular expression",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsValidRegExp),
+			},
+			"servers": {
+				Description: "The servers matching the filters",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Description: "UUID of the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"hostname": {
+							Description: "Hostname of the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"title": {
+							Description: "Title of the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"zone": {
+							Description: "The zone in which the server resides",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"plan": {
+							Description: "The pricing plan used for the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"state": {
+							Description: "The current state of the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"tags": {
+							Description: "The server related tags",
+							Type:        schema.TypeSet,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+						},
+						"labels": {
+							Description: "Labels of the server",
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+						},
+						"public_ipv4_address": {
+							Description: "The public IPv4 address of the server",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"ip_addresses": {
+							Description: "The IP addresses of the server network interfaces",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"address": {
+										Description: "The IP address",
+										Type:        schema.TypeString,
+										Computed:    true,
+									},
+									"family": {
+										Description: "The IP address family (one of `IPv4` or `IPv6`)",
+										Type:        schema.TypeString,
+										Computed:    true,
+									},
+									"type": {
+										Description: "The type of the network interface (one of `public`, `utility` or `private`)",
+										Type:        schema.TypeString,
+										Computed:    true,
+									},
+									"network": {
+										Description: "The UUID of the network the interface is attached to",
+										Type:        schema.TypeString,
+										Computed:    true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceServersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+
+	selector, err := utils.ParseLabelSelector(utils.ExpandStrings(d.Get("label_selector")))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var filters []func(upcloud.Server) (bool, error)
+	if zone, ok := d.GetOk("zone"); ok {
+		filters = append(filters, func(s upcloud.Server) (bool, error) {
+			return s.Zone == zone.(string), nil
+		})
+	}
+	if state, ok := d.GetOk("state"); ok {
+		filters = append(filters, func(s upcloud.Server) (bool, error) {
+			return s.State == state.(string), nil
+		})
+	}
+	if plan, ok := d.GetOk("plan"); ok {
+		filters = append(filters, func(s upcloud.Server) (bool, error) {
+			return s.Plan == plan.(string), nil
+		})
+	}
+	if hostnameRegex, ok := d.GetOk("hostname_regex"); ok {
+		filters = append(filters, func(s upcloud.Server) (bool, error) {
+			return regexp.MatchString(hostnameRegex.(string), s.Hostname)
+		})
+	}
+	if tags, ok := d.GetOk("tags"); ok {
+		filters = append(filters, func(s upcloud.Server) (bool, error) {
+			serverTags := sliceToMap(s.Tags)
+			for _, tag := range utils.ExpandStrings(tags) {
+				if !serverTags[tag] {
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+	}
+
+	fetchedServers, err := client.GetServers(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filteredServers, err := utils.FilterServers(fetchedServers.Servers, filters...)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	servers := []map[string]interface{}{}
+	for _, s := range filteredServers {
+		// Labels and IP addresses are only included in the server details
+		server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: s.UUID})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		labels := utils.LabelsSliceToMap(server.Labels)
+		if !selector.Matches(labels) {
+			continue
+		}
+
+		ipAddresses := []map[string]interface{}{}
+		for _, iface := range server.Networking.Interfaces {
+			for _, ip := range iface.IPAddresses {
+				ipAddresses = append(ipAddresses, map[string]interface{}{
+					"address": ip.Address,
+					"family":  ip.Family,
+					"type":    iface.Type,
+					"network": iface.Network,
+				})
+			}
+		}
+
+		servers = append(servers, map[string]interface{}{
+			"id":                  server.UUID,
+			"hostname":            server.Hostname,
+			"title":               server.Title,
+			"zone":                server.Zone,
+			"plan":                server.Plan,
+			"state":               server.State,
+			"tags":                []string(server.Tags),
+			"labels":              labels,
+			"public_ipv4_address": publicIPv4Address(server),
+			"ip_addresses":        ipAddresses,
+		})
+	}
+
+	if err := d.Set("servers", servers); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(time.Now().UTC().String())
+
+	return nil
+}
+
+func DataSourceServerPlans() *schema.Resource {
+	return &schema.Resource{
+		Description: `Use this data source to list server plans matching the given filters. All defined filters must match.
+
+The plans are ordered from the smallest to the largest by the number of CPU cores, the amount of memory and the storage size. Set ` + "`smallest_matching`" + ` to ` + "`true`" + ` to select only the smallest plan that matches the filters, e.g. to select a plan with at least 4 CPU cores and 8 GB of memory without hard-coding its name.`,
+		ReadContext: dataSourceServerPlansRead,
+		Schema: map[string]*schema.Schema{
+			"min_cpu": {
+				Description:      "If specified, this data source will return only plans with at least this many CPU cores",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"min_memory_gb": {
+				Description:      "If specified, this data source will return only plans with at least this much memory (in gigabytes)",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"min_storage_gb": {
+				Description:      "If specified, this data source will return only plans with at least this much storage (in gigabytes)",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"storage_tier": {
+				Description: "If specified, this data source will return only plans with this storage tier, e.g. `maxiops`",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"name_regex": {
+				Description:      "If specified, this data source will return only plans whose name matches this regular expression",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsValidRegExp),
+			},
+			"smallest_matching": {
+				Description: "If set to `true`, this data source will return only the smallest plan matching the filters. Reading the data source fails, if no plan matches the filters.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"plans": {
+				Description: "The plans matching the filters, ordered from the smallest to the largest",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description: "The name of the plan, e.g. `2xCPU-4GB`",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"cpu": {
+							Description: "The number of CPU cores",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"mem": {
+							Description: "The amount of memory (in megabytes)",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"storage_size": {
+							Description: "The size of the storage included in the plan (in gigabytes)",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"storage_tier": {
+							Description: "The tier of the storage included in the plan",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"public_traffic_out": {
+							Description: "The amount of outgoing public traffic included in the plan (in gigabytes)",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceServerPlansRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		var err error
+		if nameRegex, err = regexp.Compile(v.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	fetchedPlans, err := client.GetPlans(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error fetching plans: %w", err))
+	}
+
+	var matchingPlans []upcloud.Plan
+	for _, plan := range fetchedPlans.Plans {
+		if planMatches(d, nameRegex, plan) {
+			matchingPlans = append(matchingPlans, plan)
+		}
+	}
+	sort.SliceStable(matchingPlans, func(i, j int) bool {
+		a, b := matchingPlans[i], matchingPlans[j]
+		if a.CoreNumber != b.CoreNumber {
+			return a.CoreNumber < b.CoreNumber
+		}
+		if a.MemoryAmount != b.MemoryAmount {
+			return a.MemoryAmount < b.MemoryAmount
+		}
+		if a.StorageSize != b.StorageSize {
+			return a.StorageSize < b.StorageSize
+		}
+		return a.Name < b.Name
+	})
+
+	if d.Get("smallest_matching").(bool) {
+		if len(matchingPlans) == 0 {
+			return diag.Errorf("query returned no results")
+		}
+		matchingPlans = matchingPlans[:1]
+	}
+
+	plans := make([]map[string]interface{}, 0, len(matchingPlans))
+	for _, plan := range matchingPlans {
+		plans = append(plans, map[string]interface{}{
+			"name":               plan.Name,
+			"cpu":                plan.CoreNumber,
+			"mem":                plan.MemoryAmount,
+			"storage_size":       plan.StorageSize,
+			"storage_tier":       plan.StorageTier,
+			"public_traffic_out": plan.PublicTrafficOut,
+		})
+	}
+
+	if err := d.Set("plans", plans); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(time.Now().UTC().String())
+
+	return nil
+}
+
+// planMatches reports whether the plan matches all filters of the server plans data source.
+func planMatches(d *schema.ResourceData, nameRegex *regexp.Regexp, plan upcloud.Plan) bool {
+	if plan.CoreNumber < d.Get("min_cpu").(int) {
+		return false
+	}
+	if plan.MemoryAmount < d.Get("min_memory_gb").(int)*1024 {
+		return false
+	}
+	if plan.StorageSize < d.Get("min_storage_gb").(int) {
+		return false
+	}
+	if tier, ok := d.GetOk("storage_tier"); ok && plan.StorageTier != tier.(string) {
+		return false
+	}
+	if nameRegex != nil && !nameRegex.MatchString(plan.Name) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+)
+
+func TestDataSourceServer(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+
+	legacy := fakeapi.CreateServer(t, meta.Service, "legacy.example.com", fakeapi.WithLabels(upcloud.Label{Key: "env", Value: "prod"}, upcloud.Label{Key: "role", Value: "web"}))
+	fakeapi.CreateServer(t, meta.Service, "other.example.com", fakeapi.WithLabels(upcloud.Label{Key: "env", Value: "prod"}, upcloud.Label{Key: "role", Value: "db"}))
+
+	read := func(raw map[string]interface{}) (*schema.ResourceData, diag.Diagnostics) {
+		r := DataSourceServer()
+		d := schema.TestResourceDataRaw(t, r.Schema, raw)
+		return d, r.ReadContext(context.Background(), d, meta)
+	}
+
+	d, diags := read(map[string]interface{}{"hostname": "legacy.example.com"})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, legacy.UUID, d.Id())
+	assert.Equal(t, "1xCPU-1GB", d.Get("plan"))
+	assert.Equal(t, map[string]interface{}{"env": "prod", "role": "web"}, d.Get("labels"))
+	assert.Equal(t, upcloud.NetworkTypePublic, d.Get("network_interface.0.type"))
+	assert.NotEmpty(t, d.Get("network_interface.0.ip_address"))
+	assert.Equal(t, legacy.StorageDevices[0].UUID, d.Get("storage_devices.0.storage"))
+
+	d, diags = read(map[string]interface{}{"labels": map[string]interface{}{"role": "web"}})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, legacy.UUID, d.Id())
+
+	d, diags = read(map[string]interface{}{"id": legacy.UUID})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, "legacy.example.com", d.Get("hostname"))
+
+	_, diags = read(map[string]interface{}{"labels": map[string]interface{}{"env": "prod"}})
+	require.True(t, diags.HasError())
+	assert.Equal(t, "query returned more than one result", diags[0].Summary)
+
+	_, diags = read(map[string]interface{}{"hostname": "legacy.example.com", "zone": "de-fra1"})
+	require.True(t, diags.HasError())
+	assert.Equal(t, "query returned no results", diags[0].Summary)
+}
+
+func TestDataSourceServers(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+
+	web1 := fakeapi.CreateServer(t, meta.Service, "web-1.example.com", fakeapi.WithLabels(upcloud.Label{Key: "env", Value: "prod"}, upcloud.Label{Key: "role", Value: "web"}))
+	web2 := fakeapi.CreateServer(t, meta.Service, "web-2.example.com", fakeapi.WithLabels(upcloud.Label{Key: "env", Value: "dev"}, upcloud.Label{Key: "role", Value: "web"}))
+	fakeapi.CreateServer(t, meta.Service, "db-1.example.com", fakeapi.WithLabels(upcloud.Label{Key: "env", Value: "prod"}, upcloud.Label{Key: "role", Value: "db"}))
+
+	list := func(raw map[string]interface{}) []string {
+		t.Helper()
+		r := DataSourceServers()
+		d := schema.TestResourceDataRaw(t, r.Schema, raw)
+		diags := r.ReadContext(context.Background(), d, meta)
+		require.False(t, diags.HasError(), diags)
+
+		var uuids []string
+		for _, s := range d.Get("servers").([]interface{}) {
+			uuids = append(uuids, s.(map[string]interface{})["id"].(string))
+		}
+		return uuids
+	}
+
+	assert.Len(t, list(map[string]interface{}{}), 3)
+	assert.Len(t, list(map[string]interface{}{"zone": "fi-hel1", "plan": "1xCPU-1GB"}), 3)
+	assert.Empty(t, list(map[string]interface{}{"zone": "de-fra1"}))
+	assert.ElementsMatch(t, []string{web1.UUID, web2.UUID}, list(map[string]interface{}{"hostname_regex": "^web-"}))
+	assert.ElementsMatch(t, []string{web1.UUID}, list(map[string]interface{}{
+		"hostname_regex": "^web-",
+		"label_selector": []interface{}{"env in (prod, staging)"},
+	}))
+	assert.ElementsMatch(t, []string{web2.UUID}, list(map[string]interface{}{"label_selector": []interface{}{"role=web", "env!=prod"}}))
+
+	r := DataSourceServers()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"hostname_regex": "^web-1"})
+	require.False(t, r.ReadContext(context.Background(), d, meta).HasError())
+	assert.Equal(t, "web-1.example.com", d.Get("servers.0.hostname"))
+	assert.Equal(t, "prod", d.Get("servers.0.labels.env"))
+	assert.NotEmpty(t, d.Get("servers.0.public_ipv4_address"))
+	assert.Equal(t, d.Get("servers.0.public_ipv4_address"), d.Get("servers.0.ip_addresses.0.address"))
+}
+
+func TestDataSourceServerPlans(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+
+	list := func(raw map[string]interface{}) ([]string, diag.Diagnostics) {
+		t.Helper()
+		r := DataSourceServerPlans()
+		d := schema.TestResourceDataRaw(t, r.Schema, raw)
+		diags := r.ReadContext(context.Background(), d, meta)
+
+		var names []string
+		for _, p := range d.Get("plans").([]interface{}) {
+			names = append(names, p.(map[string]interface{})["name"].(string))
+		}
+		return names, diags
+	}
+
+	names, diags := list(map[string]interface{}{})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, []string{"1xCPU-1GB", "1xCPU-2GB", "2xCPU-4GB", "4xCPU-8GB"}, names)
+
+	names, diags = list(map[string]interface{}{"min_cpu": 2})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, []string{"2xCPU-4GB", "4xCPU-8GB"}, names)
+
+	names, diags = list(map[string]interface{}{"min_memory_gb": 2, "smallest_matching": true})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, []string{"1xCPU-2GB"}, names)
+
+	names, diags = list(map[string]interface{}{"min_cpu": 4, "min_memory_gb": 8, "min_storage_gb": 100, "smallest_matching": true})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, []string{"4xCPU-8GB"}, names)
+
+	names, diags = list(map[string]interface{}{"name_regex": "^1xCPU-", "storage_tier": upcloud.StorageTierMaxIOPS})
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, []string{"1xCPU-1GB", "1xCPU-2GB"}, names)
+
+	names, diags = list(map[string]interface{}{"min_cpu": 8})
+	require.False(t, diags.HasError(), diags)
+	assert.Empty(t, names)
+
+	_, diags = list(map[string]interface{}{"min_cpu": 8, "smallest_matching": true})
+	assert.True(t, diags.HasError())
+}
@@ -100,3 +100,24 @@ func TestInterfacesEquals(t *testing.T) {
 		Type: upcloud.NetworkTypePublic,
 	}))
 }
+
+func TestManagedNetworkInterfaces(t *testing.T) {
+	server := &upcloud.ServerDetails{Networking: upcloud.ServerNetworking{Interfaces: upcloud.ServerInterfaceSlice{
+		{Index: 1, MAC: "ee:1b:db:ca:00:01", Type: upcloud.NetworkTypePublic},
+		// Interface managed with upcloud_server_network_interface using an explicit index
+		{Index: 2, MAC: "ee:1b:db:ca:00:02", Type: upcloud.NetworkTypeUtility},
+		{Index: 3, MAC: "ee:1b:db:ca:00:03", Type: upcloud.NetworkTypeUtility},
+	}}}
+
+	d := ResourceServer().TestResourceData()
+	require.NoError(t, d.Set("ignore_external_network_interfaces", true))
+	require.NoError(t, d.Set("network_interface", []interface{}{
+		map[string]interface{}{"type": upcloud.NetworkTypePublic, "mac_address": "ee:1b:db:ca:00:01"},
+		map[string]interface{}{"type": upcloud.NetworkTypeUtility, "mac_address": "ee:1b:db:ca:00:03"},
+	}))
+
+	managed := managedNetworkInterfaces(d, server)
+	require.Len(t, managed, 2)
+	assert.Equal(t, 1, managed[0].Index)
+	assert.Equal(t, 3, managed[1].Index)
+}
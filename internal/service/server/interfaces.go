@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"net/netip"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
-func reconfigureServerNetworkInterfaces(ctx context.Context, svc *service.Service, d *schema.ResourceData) error {
+func reconfigureServerNetworkInterfaces(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*config.Meta).Service
 	// assert server is stopped
 	s, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{
 		UUID: d.Id(),
@@ -29,17 +32,29 @@ func reconfigureServerNetworkInterfaces(ctx context.Context, svc *service.Servic
 		return err
 	}
 
+	// Interfaces managed outside of the server resource are not included in the state of the server resource
+	ignoreExternal := d.Get("ignore_external_network_interfaces").(bool)
+	oldInterfaces, _ := d.GetChange("network_interface")
+	managedMACs := networkInterfaceMACs(oldInterfaces.([]interface{}))
+	external := make(map[int]bool)
+
 	// Try to preserve public (IPv4 or IPv6) and utility network interfaces so that IPs doesn't change
 	preserveInterfaces := make(map[int]bool, 0)
 	// flush interfaces
-	for i, n := range s.Networking.Interfaces {
-		if (n.Type == upcloud.NetworkTypePublic || n.Type == upcloud.NetworkTypeUtility) && len(reqs) > i && interfacesEquals(n, reqs[i]) {
+	for _, n := range s.Networking.Interfaces {
+		if ignoreExternal && isExternalNetworkInterface(n, managedMACs) {
+			external[n.Index] = true
+			continue
+		}
+		if (n.Type == upcloud.NetworkTypePublic || n.Type == upcloud.NetworkTypeUtility) && len(reqs) >= n.Index && interfacesEquals(n, reqs[n.Index-1]) {
 			preserveInterfaces[n.Index] = true
 			continue
 		}
-		if err := svc.DeleteNetworkInterface(ctx, &request.DeleteNetworkInterfaceRequest{
-			ServerUUID: d.Id(),
-			Index:      n.Index,
+		if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			return svc.DeleteNetworkInterface(ctx, &request.DeleteNetworkInterfaceRequest{
+				ServerUUID: d.Id(),
+				Index:      n.Index,
+			})
 		}); err != nil {
 			return fmt.Errorf("unable to delete interface #%d; %w", n.Index, err)
 		}
@@ -49,11 +64,34 @@ func reconfigureServerNetworkInterfaces(ctx context.Context, svc *service.Servic
 		if _, ok := preserveInterfaces[r.Index]; ok && (r.Type == upcloud.NetworkTypePublic || r.Type == upcloud.NetworkTypeUtility) {
 			continue
 		}
-		if _, err := svc.CreateNetworkInterface(ctx, &r); err != nil {
+		if external[r.Index] {
+			return fmt.Errorf("unable to create interface #%d; the index is used by a network interface that is not managed by the server resource", r.Index)
+		}
+		if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := svc.CreateNetworkInterface(ctx, &r)
+			return err
+		}); err != nil {
 			return fmt.Errorf("unable to create interface #%d; %w", r.Index, err)
 		}
 	}
-	return nil
+	if !ignoreExternal {
+		return nil
+	}
+
+	// Store the MAC addresses of the created interfaces, so that they are not considered external when reading the server
+	s, err = svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{
+		UUID: d.Id(),
+	})
+	if err != nil {
+		return err
+	}
+	managed := make([]upcloud.ServerInterface, 0, len(reqs))
+	for _, n := range s.Networking.Interfaces {
+		if !external[n.Index] {
+			managed = append(managed, n)
+		}
+	}
+	return d.Set("network_interface", flattenNetworkInterfaces(managed))
 }
 
 func networkInterfacesFromResourceData(ctx context.Context, svc *service.Service, d *schema.ResourceData) ([]request.CreateNetworkInterfaceRequest, error) {
@@ -152,3 +190,75 @@ func interfacesEquals(a upcloud.ServerInterface, b request.CreateNetworkInterfac
 	}
 	return true
 }
+
+// flattenNetworkInterfaces returns the network interfaces as network_interface blocks.
+func flattenNetworkInterfaces(interfaces []upcloud.ServerInterface) []map[string]interface{} {
+	networkInterfaces := []map[string]interface{}{}
+	for _, iface := range interfaces {
+		ni := make(map[string]interface{})
+		ni["ip_address_family"] = iface.IPAddresses[0].Family
+		ni["ip_address"] = iface.IPAddresses[0].Address
+		if !iface.IPAddresses[0].Floating.Empty() {
+			ni["ip_address_floating"] = iface.IPAddresses[0].Floating.Bool()
+		}
+		ni["mac_address"] = iface.MAC
+		ni["network"] = iface.Network
+		ni["type"] = iface.Type
+		if !iface.Bootable.Empty() {
+			ni["bootable"] = iface.Bootable.Bool()
+		}
+		if !iface.SourceIPFiltering.Empty() {
+			ni["source_ip_filtering"] = iface.SourceIPFiltering.Bool()
+		}
+
+		networkInterfaces = append(networkInterfaces, ni)
+	}
+	return networkInterfaces
+}
+
+// publicIPv4Address returns the IPv4 address of the last public network interface of the server.
+func publicIPv4Address(server *upcloud.ServerDetails) string {
+	var ip string
+	for _, iface := range server.Networking.Interfaces {
+		if iface.Type == upcloud.NetworkTypePublic &&
+			iface.IPAddresses[0].Family == upcloud.IPAddressFamilyIPv4 {
+			ip = iface.IPAddresses[0].Address
+		}
+	}
+	return ip
+}
+
+// managedNetworkInterfaces returns the network interfaces of the server that are defined in the server resource. If
+// ignore_external_network_interfaces is enabled, interfaces whose MAC addresses are not in the state are omitted.
+func managedNetworkInterfaces(d *schema.ResourceData, server *upcloud.ServerDetails) []upcloud.ServerInterface {
+	if !d.Get("ignore_external_network_interfaces").(bool) {
+		return server.Networking.Interfaces
+	}
+
+	managedMACs := networkInterfaceMACs(d.Get("network_interface").([]interface{}))
+	interfaces := make([]upcloud.ServerInterface, 0, len(managedMACs))
+	for _, iface := range server.Networking.Interfaces {
+		if !isExternalNetworkInterface(iface, managedMACs) {
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return interfaces
+}
+
+// networkInterfaceMACs returns the MAC addresses of the network_interface blocks that have been read from the server.
+func networkInterfaceMACs(interfaces []interface{}) map[string]bool {
+	macs := make(map[string]bool)
+	for _, iface := range interfaces {
+		if m, ok := iface.(map[string]interface{}); ok && m["mac_address"] != "" && m["mac_address"] != nil {
+			macs[m["mac_address"].(string)] = true
+		}
+	}
+	return macs
+}
+
+// isExternalNetworkInterface reports whether the interface is managed outside of the server resource. The state of new
+// and imported servers does not include any MAC addresses yet, so all interfaces of those servers are considered to be
+// managed by the server resource.
+func isExternalNetworkInterface(iface upcloud.ServerInterface, managedMACs map[string]bool) bool {
+	return len(managedMACs) > 0 && !managedMACs[iface.MAC]
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func ResourceServerNetworkInterface() *schema.Resource {
+	return &schema.Resource{
+		Description: `This resource attaches a network interface to an existing server. The server is stopped while the interface is attached, modified or detached, and started again afterwards, if it was running. The server is stopped as defined by ` + "`stop_type`" + ` and ` + "`graceful_shutdown_timeout`" + `. Set ` + "`allow_stop_for_update`" + ` to ` + "`false`" + ` to fail instead of stopping a running server.
+
+Set ` + "`ignore_external_network_interfaces`" + ` to ` + "`true`" + ` in the ` + "`upcloud_server`" + ` resource to prevent it from removing interfaces managed with this resource.`,
+		CreateContext: resourceServerNetworkInterfaceCreate,
+		ReadContext:   resourceServerNetworkInterfaceRead,
+		UpdateContext: resourceServerNetworkInterfaceUpdate,
+		DeleteContext: resourceServerNetworkInterfaceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 10),
+			Update: schema.DefaultTimeout(time.Minute * 10),
+			Delete: schema.DefaultTimeout(time.Minute * 10),
+		},
+		Schema: utils.JoinSchemas(map[string]*schema.Schema{
+			"server_id": {
+				Description: "The UUID of the server the network interface is attached to.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"type": {
+				Description: "Network interface type (one of `public`, `utility` or `private`). For private network interfaces, a network must be specified with an existing network id.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.NetworkTypePublic,
+					upcloud.NetworkTypeUtility,
+					upcloud.NetworkTypePrivate,
+				}, false)),
+			},
+			"network": {
+				Description: "The unique ID of the network to attach the interface to. Required for private network interfaces.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"ip_address_family": {
+				Description: "The IP address type of the interface (one of `IPv4` or `IPv6`).",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     upcloud.IPAddressFamilyIPv4,
+				ForceNew:    true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.IPAddressFamilyIPv4,
+					upcloud.IPAddressFamilyIPv6,
+				}, false)),
+			},
+			"ip_address": {
+				Description: "The IP address of the interface. A fixed IP address can be defined for private network interfaces, otherwise the address is assigned automatically.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"index": {
+				Description:      "The index of the interface. If not defined, the next available index is used.",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"mac_address": {
+				Description: "The assigned MAC address.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"source_ip_filtering": {
+				Description: "`true` if source IP should be filtered.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"bootable": {
+				Description: "`true` if this interface should be used for network booting.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+		}, utils.ServerStopSchema("to attach, modify or detach the network interface")),
+	}
+}
+
+func resourceServerNetworkInterfaceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID := d.Get("server_id").(string)
+	defer utils.LockServers(ctx, meta, serverUUID)()
+
+	r := &request.CreateNetworkInterfaceRequest{
+		ServerUUID:        serverUUID,
+		Type:              d.Get("type").(string),
+		Index:             d.Get("index").(int),
+		SourceIPFiltering: upcloud.FromBool(d.Get("source_ip_filtering").(bool)),
+		Bootable:          upcloud.FromBool(d.Get("bootable").(bool)),
+		IPAddresses: request.CreateNetworkInterfaceIPAddressSlice{{
+			Family: d.Get("ip_address_family").(string),
+		}},
+	}
+	if r.Type == upcloud.NetworkTypePrivate {
+		r.NetworkUUID = d.Get("network").(string)
+		r.IPAddresses[0].Address = d.Get("ip_address").(string)
+		if r.NetworkUUID == "" {
+			return diag.Errorf("network must be defined for private network interfaces")
+		}
+	}
+
+	var iface *upcloud.Interface
+	err := withServerStopped(ctx, d, serverUUID, d.Timeout(schema.TimeoutCreate), meta, func() error {
+		return utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutCreate), func() (err error) {
+			iface, err = client.CreateNetworkInterface(ctx, r)
+			return err
+		})
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(marshalNetworkInterfaceID(serverUUID, iface.Index))
+	tflog.Info(ctx, "network interface created", map[string]interface{}{"server_uuid": serverUUID, "index": iface.Index})
+
+	return resourceServerNetworkInterfaceRead(ctx, d, meta)
+}
+
+func resourceServerNetworkInterfaceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID, index, err := unmarshalNetworkInterfaceID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	networking, err := client.GetServerNetworks(ctx, &request.GetServerNetworksRequest{ServerUUID: serverUUID})
+	if err != nil {
+		return utils.HandleResourceError(d.Id(), d, err)
+	}
+
+	var iface *upcloud.Interface
+	for i := range networking.Interfaces {
+		if networking.Interfaces[i].Index == index {
+			iface = (*upcloud.Interface)(&networking.Interfaces[i])
+			break
+		}
+	}
+	if iface == nil {
+		tflog.Warn(ctx, "network interface not found, removing it from the state", map[string]interface{}{"server_uuid": serverUUID, "index": index})
+		d.SetId("")
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"server_id":   serverUUID,
+		"index":       iface.Index,
+		"type":        iface.Type,
+		"network":     iface.Network,
+		"mac_address": iface.MAC,
+	}
+	if len(iface.IPAddresses) > 0 {
+		data["ip_address_family"] = iface.IPAddresses[0].Family
+		data["ip_address"] = iface.IPAddresses[0].Address
+	}
+	if !iface.SourceIPFiltering.Empty() {
+		data["source_ip_filtering"] = iface.SourceIPFiltering.Bool()
+	}
+	if !iface.Bootable.Empty() {
+		data["bootable"] = iface.Bootable.Bool()
+	}
+	for k, v := range data {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+func resourceServerNetworkInterfaceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID, index, err := unmarshalNetworkInterfaceID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer utils.LockServers(ctx, meta, serverUUID)()
+
+	// Changes to the stop arguments only affect how the server is stopped in later operations
+	if !d.HasChanges("source_ip_filtering", "bootable") {
+		return resourceServerNetworkInterfaceRead(ctx, d, meta)
+	}
+
+	r := &request.ModifyNetworkInterfaceRequest{
+		ServerUUID:        serverUUID,
+		CurrentIndex:      index,
+		SourceIPFiltering: upcloud.FromBool(d.Get("source_ip_filtering").(bool)),
+		Bootable:          upcloud.FromBool(d.Get("bootable").(bool)),
+	}
+	err = withServerStopped(ctx, d, serverUUID, d.Timeout(schema.TimeoutUpdate), meta, func() error {
+		return utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := client.ModifyNetworkInterface(ctx, r)
+			return err
+		})
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceServerNetworkInterfaceRead(ctx, d, meta)
+}
+
+func resourceServerNetworkInterfaceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID, index, err := unmarshalNetworkInterfaceID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer utils.LockServers(ctx, meta, serverUUID)()
+
+	err = withServerStopped(ctx, d, serverUUID, d.Timeout(schema.TimeoutDelete), meta, func() error {
+		return utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutDelete), func() error {
+			return client.DeleteNetworkInterface(ctx, &request.DeleteNetworkInterfaceRequest{
+				ServerUUID: serverUUID,
+				Index:      index,
+			})
+		})
+	})
+	if err != nil {
+		return utils.HandleResourceError(d.Id(), d, err)
+	}
+
+	tflog.Info(ctx, "network interface deleted", map[string]interface{}{"server_uuid": serverUUID, "index": index})
+	return nil
+}
+
+// withServerStopped stops the server for the duration of fn and starts it again afterwards, if it was started before.
+// The server is stopped as defined by the stop_type and graceful_shutdown_timeout of the resource. If the server is
+// started and allow_stop_for_update of the resource is disabled, an error is returned without calling fn.
+func withServerStopped(ctx context.Context, d *schema.ResourceData, serverUUID string, timeout time.Duration, meta interface{}, fn func() error) error {
+	client := meta.(*config.Meta).Service
+	server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: serverUUID})
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckServerStopAllowed(d, server); err != nil {
+		return err
+	}
+
+	if err := utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, serverUUID), timeout, meta); err != nil {
+		return err
+	}
+	err = fn()
+
+	// Start the server even if fn failed to not leave it stopped
+	if server.State != upcloud.ServerStateStopped {
+		if startErr := utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: serverUUID}, timeout, meta); startErr != nil {
+			return errors.Join(err, startErr)
+		}
+	}
+	return err
+}
+
+func marshalNetworkInterfaceID(serverUUID string, index int) string {
+	return fmt.Sprintf("%s/%d", serverUUID, index)
+}
+
+func unmarshalNetworkInterfaceID(id string) (serverUUID string, index int, err error) {
+	serverUUID, i, ok := strings.Cut(id, "/")
+	if ok {
+		index, err = strconv.Atoi(i)
+	}
+	if !ok || err != nil {
+		return "", 0, fmt.Errorf("invalid network interface ID '%s', expected format <server UUID>/<index>", id)
+	}
+	return serverUUID, index, nil
+}
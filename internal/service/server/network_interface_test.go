@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+)
+
+func TestResourceServerNetworkInterface(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	server := fakeapi.CreateServer(t, meta.Service, "nic.example.com")
+
+	r := ResourceServerNetworkInterface()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"server_id": server.UUID,
+		"type":      upcloud.NetworkTypeUtility,
+	})
+	diags := r.CreateContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, server.UUID+"/2", d.Id())
+	assert.Equal(t, 2, d.Get("index"))
+	assert.NotEmpty(t, d.Get("ip_address"))
+
+	// Server is started again after attaching the interface
+	details, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, details.State)
+	require.Len(t, details.Networking.Interfaces, 2)
+
+	// Server resource ignores the interface, if configured to do so
+	sd := ResourceServer().TestResourceData()
+	require.NoError(t, sd.Set("network_interface", []interface{}{map[string]interface{}{
+		"type":        upcloud.NetworkTypePublic,
+		"mac_address": details.Networking.Interfaces[0].MAC,
+	}}))
+	assert.Len(t, managedNetworkInterfaces(sd, details), 2)
+	require.NoError(t, sd.Set("ignore_external_network_interfaces", true))
+	assert.Len(t, managedNetworkInterfaces(sd, details), 1)
+
+	// State of imported servers does not include the interfaces yet, so all interfaces are read
+	imported := ResourceServer().TestResourceData()
+	require.NoError(t, imported.Set("ignore_external_network_interfaces", true))
+	assert.Len(t, managedNetworkInterfaces(imported, details), 2)
+
+	diags = r.DeleteContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+	details, err = meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Len(t, details.Networking.Interfaces, 1)
+
+	// Started server is not stopped, if stopping is not allowed
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"server_id":             server.UUID,
+		"type":                  upcloud.NetworkTypeUtility,
+		"allow_stop_for_update": false,
+	})
+	diags = r.CreateContext(ctx, d, meta)
+	require.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Summary, "allow_stop_for_update is disabled")
+	details, err = meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, details.State)
+	assert.Len(t, details.Networking.Interfaces, 1)
+
+	_, _, err = unmarshalNetworkInterfaceID("invalid")
+	assert.Error(t, err)
+}
+
+func TestResourceServer_ignoreExternalNetworkInterfaces(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	r := ResourceServer()
+	cfg := testServerConfig("external-nic.example.com", map[string]interface{}{
+		"ignore_external_network_interfaces": true,
+		"network_interface": []interface{}{
+			map[string]interface{}{"type": upcloud.NetworkTypePublic},
+			map[string]interface{}{"type": upcloud.NetworkTypeUtility},
+		},
+	})
+	d := schema.TestResourceDataRaw(t, r.Schema, cfg)
+	diags := r.CreateContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+
+	nic := ResourceServerNetworkInterface()
+	nd := schema.TestResourceDataRaw(t, nic.Schema, map[string]interface{}{
+		"server_id": d.Id(),
+		"type":      upcloud.NetworkTypeUtility,
+		"index":     5,
+	})
+	diags = nic.CreateContext(ctx, nd, meta)
+	require.False(t, diags.HasError(), diags)
+	require.False(t, r.ReadContext(ctx, d, meta).HasError())
+	require.Len(t, d.Get("network_interface"), 2)
+
+	// Replacing an interface of the server resource keeps the external interface and stores the MAC address of the
+	// new interface
+	oldMAC := d.Get("network_interface.0.mac_address").(string)
+	cfg["network_interface"].([]interface{})[0] = map[string]interface{}{
+		"type":              upcloud.NetworkTypePublic,
+		"ip_address_family": upcloud.IPAddressFamilyIPv6,
+	}
+	state := d.State()
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{"zone": cty.StringVal("fi-hel1")})
+	diff, err := r.SimpleDiff(ctx, state, terraform.NewResourceConfigRaw(cfg), meta)
+	require.NoError(t, err)
+	d, err = schema.InternalMap(r.Schema).Data(state, diff)
+	require.NoError(t, err)
+	diags = r.UpdateContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+
+	require.Len(t, d.Get("network_interface"), 2)
+	assert.Equal(t, upcloud.IPAddressFamilyIPv6, d.Get("network_interface.0.ip_address_family"))
+	assert.NotEqual(t, oldMAC, d.Get("network_interface.0.mac_address"))
+	details, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	require.NoError(t, err)
+	require.Len(t, details.Networking.Interfaces, 3)
+	macs := make(map[int]string)
+	for _, iface := range details.Networking.Interfaces {
+		macs[iface.Index] = iface.MAC
+	}
+	assert.Equal(t, nd.Get("mac_address"), macs[5])
+	assert.Equal(t, d.Get("network_interface.0.mac_address"), macs[1])
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/storage"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+)
+
+// resourceChanges is implemented by both schema.ResourceData and schema.ResourceDiff.
+type resourceChanges interface {
+	Get(key string) interface{}
+	GetChange(key string) (interface{}, interface{})
+	HasChange(key string) bool
+	GetRawConfig() cty.Value
+}
+
+// hasBootDiskRebuild reports whether the boot disk should be rebuilt in place, either because the template changed and
+// reimage is enabled or because the rebuild trigger changed from a previously set value.
+func hasBootDiskRebuild(d resourceChanges) bool {
+	if !isImportedServer(d) && d.HasChange("template.0.storage") && d.Get("template.0.reimage").(bool) {
+		return true
+	}
+	oldTrigger, _ := d.GetChange("template.0.rebuild_trigger")
+	return d.HasChange("template.0.rebuild_trigger") && oldTrigger.(string) != ""
+}
+
+// forceNewTemplateStorageChange replaces the server when the template changes, unless the boot disk is rebuilt in place.
+// Changes to the template of imported servers are suppressed, see suppressImportedServerDiff.
+func forceNewTemplateStorageChange(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || isImportedServer(d) || !d.HasChange("template.0.storage") || hasBootDiskRebuild(d) {
+		return nil
+	}
+	if err := utils.CheckReplacementAllowed(d, "template.0.storage"); err != nil {
+		return err
+	}
+	return d.ForceNew("template.0.storage")
+}
+
+// validateImportedServerReimage fails the plan, if reimage is enabled for an imported server and the configured template
+// differs from the boot disk. The template the server was created from cannot be determined, so it is not known
+// whether the template changed. Changes to the template of imported servers are suppressed, see
+// suppressImportedServerDiff.
+func validateImportedServerReimage(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || !isImportedServer(d) || !d.Get("template.0.reimage").(bool) {
+		return nil
+	}
+	if bootDisk, _ := d.GetChange("template.0.storage"); configuredTemplateStorage(d) == bootDisk.(string) {
+		return nil
+	}
+	return errors.New("reimage cannot detect changes to template.0.storage of imported servers, as the template the server was created from cannot be determined. " +
+		"Set reimage to false and use rebuild_trigger to rebuild the boot disk from template.0.storage")
+}
+
+// rebuildBootDisk replaces the boot disk of a stopped server with a new clone of the configured template. The new disk
+// is attached to the same address as the previous one, and the previous disk is deleted.
+func rebuildBootDisk(ctx context.Context, d *schema.ResourceData, meta interface{}, server *upcloud.ServerDetails) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	timeout := d.Timeout(schema.TimeoutUpdate)
+
+	oldDisk := server.StorageDevice(d.Get("template.0.id").(string))
+	if oldDisk == nil {
+		return diag.Errorf("boot disk %s is not attached to the server", d.Get("template.0.id"))
+	}
+
+	// Use the configured template, as changes to the template of imported servers are suppressed
+	source := configuredTemplateStorage(d)
+	templateUUID, err := resolveTemplateUUID(ctx, client, source)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	title := d.Get("template.0.title").(string)
+	if title == "" {
+		title = fmt.Sprintf("terraform-%s-disk", d.Get("hostname").(string))
+	}
+
+	tflog.Info(ctx, "rebuilding server boot disk", map[string]interface{}{"uuid": d.Id(), "template": templateUUID, "previous_storage_uuid": oldDisk.UUID})
+	var newDisk *upcloud.StorageDetails
+	if err := utils.RetryWhileStorageBusy(ctx, meta, templateUUID, timeout, func() (err error) {
+		newDisk, err = client.CloneStorage(ctx, &request.CloneStorageRequest{
+			UUID:  templateUUID,
+			Zone:  server.Zone,
+			Tier:  oldDisk.Tier,
+			Title: title,
+		})
+		return err
+	}); err != nil {
+		return diag.FromErr(err)
+	}
+
+	deleteDisk := func(uuid string) error {
+		return utils.RetryWhileStorageBusy(ctx, meta, uuid, timeout, func() error {
+			return client.DeleteStorage(ctx, &request.DeleteStorageRequest{UUID: uuid})
+		})
+	}
+	// The new disk is deleted, if it cannot be attached to the server, so that it is not left behind
+	attachNewDisk := func() error {
+		if _, err := utils.WaitForStorageState(ctx, meta, newDisk.UUID, upcloud.StorageStateOnline, timeout); err != nil {
+			return err
+		}
+
+		modify := &request.ModifyStorageRequest{UUID: newDisk.UUID}
+		if size := d.Get("template.0.size").(int); size > newDisk.Size {
+			modify.Size = size
+		}
+		if backupRule, ok := d.GetOk("template.0.backup_rule.0"); ok {
+			modify.BackupRule = storage.BackupRule(backupRule.(map[string]interface{}))
+		}
+		if modify.Size != 0 || modify.BackupRule != nil {
+			if err := utils.RetryWhileStorageBusy(ctx, meta, modify.UUID, timeout, func() error {
+				_, err := client.ModifyStorage(ctx, modify)
+				return err
+			}); err != nil {
+				return err
+			}
+		}
+
+		address := oldDisk.Address
+		if d.HasChange("template.0.address") {
+			address = utils.StorageAddressFormat(d.Get("template.0.address").(string))
+		}
+		return replaceBootDisk(ctx, d, meta, oldDisk, newDisk.UUID, address)
+	}
+	if err := attachNewDisk(); err != nil {
+		if deleteErr := deleteDisk(newDisk.UUID); deleteErr != nil {
+			return diag.FromErr(errors.Join(err, fmt.Errorf("deleting new boot disk %s failed: %w", newDisk.UUID, deleteErr)))
+		}
+		return diag.FromErr(err)
+	}
+
+	// Store the new boot disk before deleting the previous one, so that the state matches the server even if deleting
+	// the previous disk fails
+	template := d.Get("template.0").(map[string]interface{})
+	template["id"] = newDisk.UUID
+	template["storage"] = source
+	template["title"] = title
+	if err := d.Set("template", []interface{}{template}); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The previous boot disk is deleted like it would be when replacing the server
+	if err := deleteDisk(oldDisk.UUID); err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "deleting the previous boot disk failed",
+			Detail:   fmt.Sprintf("The boot disk was rebuilt, but deleting the previous boot disk %s failed: %s. The disk is no longer attached to the server and must be deleted manually.", oldDisk.UUID, err),
+		}}
+	}
+	return nil
+}
+
+// replaceBootDisk detaches the previous boot disk and attaches the new one. If attaching the new disk fails, the
+// previous disk is attached back to the server.
+func replaceBootDisk(ctx context.Context, d *schema.ResourceData, meta interface{}, oldDisk *upcloud.ServerStorageDevice, newDiskUUID, address string) error {
+	client := meta.(*config.Meta).Service
+	timeout := d.Timeout(schema.TimeoutUpdate)
+
+	if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), timeout, func() error {
+		_, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
+			ServerUUID: d.Id(),
+			Address:    oldDisk.Address,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	attach := func(storageUUID, address string) error {
+		return utils.RetryWhileServerBusy(ctx, meta, d.Id(), timeout, func() error {
+			_, err := client.AttachStorage(ctx, &request.AttachStorageRequest{
+				ServerUUID:  d.Id(),
+				StorageUUID: storageUUID,
+				Address:     address,
+				Type:        upcloud.StorageTypeDisk,
+				BootDisk:    1,
+			})
+			return err
+		})
+	}
+	if err := attach(newDiskUUID, address); err != nil {
+		if restoreErr := attach(oldDisk.UUID, oldDisk.Address); restoreErr != nil {
+			return errors.Join(err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// configuredTemplateStorage returns the template storage defined in the configuration.
+func configuredTemplateStorage(d resourceChanges) string {
+	rawConfig := d.GetRawConfig()
+	if !rawConfig.IsKnown() || rawConfig.IsNull() || !rawConfig.Type().HasAttribute("template") {
+		return d.Get("template.0.storage").(string)
+	}
+	template := rawConfig.GetAttr("template")
+	if template.IsKnown() && !template.IsNull() && template.LengthInt() > 0 {
+		storage := template.Index(cty.NumberIntVal(0)).GetAttr("storage")
+		if storage.IsKnown() && !storage.IsNull() {
+			return storage.AsString()
+		}
+	}
+	return d.Get("template.0.storage").(string)
+}
@@ -10,30 +10,34 @@ import (
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/go-cty/cty"
-	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/storage"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 )
 
 const serverTitleLength int = 255
 
+// stopRequiringChanges lists the arguments that can only be modified while the server is stopped.
+var stopRequiringChanges = []string{"cpu", "mem", "plan", "timezone", "nic_model", "video_model", "template.0.size", "storage_devices", "network_interface"}
+
 func ResourceServer() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "The UpCloud server resource allows the creation, update and deletion of a server.",
 		CreateContext: resourceServerCreate,
 		ReadContext:   resourceServerRead,
 		UpdateContext: resourceServerUpdate,
 		DeleteContext: resourceServerDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 25),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
 		},
 		Schema: map[string]*schema.Schema{
 			"hostname": {
@@ -49,9 +53,10 @@ func ResourceServer() *schema.Resource {
 				ValidateFunc: validation.StringLenBetween(1, serverTitleLength),
 			},
 			"zone": {
-				Description: "The zone in which the server will be hosted, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
+				Description: "The zone in which the server will be hosted, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"firewall": {
@@ -64,6 +69,39 @@ func ResourceServer() *schema.Resource {
 				Type:        schema.TypeBool,
 				Optional:    true,
 			},
+			"remote_access_enabled": {
+				Description: "Is the remote console access (VNC or SPICE) enabled for the server",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"remote_access_type": {
+				Description: "The type of the remote console access, either `vnc` or `spice`",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     upcloud.RemoteAccessTypeVNC,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.RemoteAccessTypeVNC,
+					upcloud.RemoteAccessTypeSPICE,
+				}, false)),
+			},
+			"remote_access_password": {
+				Description: "The password for the remote console access. A password is generated, if not defined.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"remote_access_host": {
+				Description: "The hostname of the remote console access endpoint",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"remote_access_port": {
+				Description: "The port of the remote console access endpoint",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
 			"cpu": {
 				Description:   "The number of CPU for the server",
 				Type:          schema.TypeInt,
@@ -140,6 +178,54 @@ func ResourceServer() *schema.Resource {
 				Type:        schema.TypeInt,
 				Optional:    true,
 			},
+			"stop_type": {
+				Description: "The type of stop used when the server needs to be stopped. With `soft` stop, the server is asked to shut down gracefully and is forcibly stopped if it has not shut down within `graceful_shutdown_timeout`. With `hard` stop, the server is stopped immediately.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     utils.DefaultStopType,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.StopTypeSoft,
+					upcloud.StopTypeHard,
+				}, false)),
+			},
+			"graceful_shutdown_timeout": {
+				Description:      "The time (in seconds) to wait for the server to shut down gracefully before forcibly stopping it, when `stop_type` is `soft`.",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          utils.DefaultGracefulShutdownTimeout,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"allow_stop_for_update": {
+				Description: "Allow stopping the server to apply changes that can only be made to a stopped server, e.g. changes to `plan`, `cpu`, `mem` or `storage_devices`. If set to `false`, planning such changes to a started server fails.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"power_state": {
+				Description: "The power state of the server, either `started` or `stopped`. The server is started or stopped to match this value.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     upcloud.ServerStateStarted,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.ServerStateStarted,
+					upcloud.ServerStateStopped,
+				}, false)),
+			},
+			"ignore_external_network_interfaces": {
+				Description: "If set to `true`, network interfaces that are not defined in the `network_interface` blocks, e.g. interfaces managed with `upcloud_server_network_interface` resources, are ignored. " +
+					"The interfaces of the server resource are identified by the MAC addresses in the state. All interfaces of imported servers are read into the state, so import the server before creating other interfaces for it. " +
+					"The interfaces defined in the `network_interface` blocks use the first indexes, so the indexes of other interfaces must be greater than the number of `network_interface` blocks.",
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"ignore_external_storage_devices": {
+				Description: "If set to `true`, storages that are not defined in the `storage_devices` blocks, e.g. storages managed with `upcloud_server_storage_attachment` resources, are ignored.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"deletion_protection": utils.DeletionProtectionSchema("server"),
 			"network_interface": {
 				Type:        schema.TypeList,
 				Description: "One or more blocks describing the network interfaces of the server.",
@@ -226,15 +312,21 @@ func ResourceServer() *schema.Resource {
 					},
 				},
 			},
-			"labels": utils.LabelsSchema("server"),
+			"labels":     utils.LabelsSchema("server"),
+			"labels_all": utils.LabelsAllSchema("server"),
 			"user_data": {
-				Description: "Defines URL for a server setup script, or the script body itself",
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
+				Description:      "Defines URL for a server setup script, or the script body itself. Scripts starting with `#cloud-config` are validated as cloud-config documents. Changes are ignored for imported servers, as the original value cannot be determined.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ConflictsWith:    []string{"cloud_init"},
+				ValidateDiagFunc: validateUserData,
+				DiffSuppressFunc: suppressImportedServerDiff,
 			},
+			"cloud_init": cloudInitSchema(),
+			"wait_for":   waitForSchema(),
 			"plan": {
-				Description: "The pricing plan used for the server. You can list available server plans with `upctl server plans`",
+				Description: "The pricing plan used for the server. You can list available server plans with `upctl server plans` or select a plan with the `upcloud_server_plans` data source",
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
@@ -314,10 +406,27 @@ func ResourceServer() *schema.Resource {
 							ValidateFunc: validation.StringLenBetween(0, 64),
 						},
 						"storage": {
-							Description: "A valid storage UUID or template name. You can list available public templates with `upctl storage list --public --template` and available private templates with `upctl storage list --template`.",
-							Type:        schema.TypeString,
-							ForceNew:    true,
-							Required:    true,
+							Description: "A valid storage UUID or template name. You can list available public templates with `upctl storage list --public --template` and available private templates with `upctl storage list --template`. " +
+								"Changing this value replaces the server, unless `reimage` is set to `true`. " +
+								"For imported servers, the template the server was created from cannot be determined. The UUID of the boot disk is used instead and changes to this value are ignored.",
+							Type:             schema.TypeString,
+							Required:         true,
+							DiffSuppressFunc: suppressImportedServerDiff,
+						},
+						"reimage": {
+							Description: "If set to `true`, changing `storage` rebuilds the boot disk in place instead of replacing the server. " +
+								"The server is stopped, the boot disk is replaced with a new clone of the template at the same address and the server is started again. " +
+								"The server keeps its UUID, IP addresses, firewall rules and tags, but the data on the previous boot disk is lost, as the previous boot disk is deleted. " +
+								"Changes to `storage` cannot be detected for imported servers, use `rebuild_trigger` to rebuild the boot disk of imported servers instead.",
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"rebuild_trigger": {
+							Description: "Changing this value rebuilds the boot disk in place from `storage`, as described for `reimage`, e.g. to install a new version of a template with the same name. " +
+								"Setting the value for the first time does not rebuild the boot disk.",
+							Type:     schema.TypeString,
+							Optional: true,
 						},
 						"backup_rule": storage.BackupRuleSchema(),
 						"filesystem_autoresize": {
@@ -339,11 +448,12 @@ func ResourceServer() *schema.Resource {
 				},
 			},
 			"login": {
-				Description: "Configure access credentials to the server",
-				Type:        schema.TypeSet,
-				ForceNew:    true,
-				MaxItems:    1,
-				Optional:    true,
+				Description:      "Configure access credentials to the server. Changes are ignored for imported servers, as the original value cannot be determined.",
+				Type:             schema.TypeSet,
+				ForceNew:         true,
+				MaxItems:         1,
+				Optional:         true,
+				DiffSuppressFunc: suppressImportedServerDiff,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"user": {
@@ -405,15 +515,28 @@ func ResourceServer() *schema.Resource {
 				},
 			},
 		},
-		CustomizeDiff: customdiff.Sequence(
-			// Validate tags here, because in-schema validation is only available for primitive types
-			validateTagsChange,
-		),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		// Validate tags here, because in-schema validation is only available for primitive types
+		validateTagsChange,
+		forceNewTemplateStorageChange,
+		validateImportedServerReimage,
+		validateStopForUpdate,
+		utils.MergeDefaultLabels,
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	defaults := schemaDefaults(r.Schema)
+	r.Importer = &schema.ResourceImporter{
+		StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			return resourceServerImport(ctx, d, meta, defaults)
+		},
+	}
+	return r
 }
 
 func resourceServerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	diags := diag.Diagnostics{}
 
 	if err := validatePlan(ctx, client, d.Get("plan").(string)); err != nil {
@@ -450,6 +573,8 @@ func resourceServerCreate(ctx context.Context, d *schema.ResourceData, meta inte
 			"storage":                  d.Get("template.0.storage"),
 			"filesystem_autoresize":    d.Get("template.0.filesystem_autoresize"),
 			"delete_autoresize_backup": d.Get("template.0.delete_autoresize_backup"),
+			"reimage":                  d.Get("template.0.reimage"),
+			"rebuild_trigger":          d.Get("template.0.rebuild_trigger"),
 		}})
 	}
 
@@ -468,21 +593,36 @@ func resourceServerCreate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 	}
 
-	_, err = client.WaitForServerState(ctx, &request.WaitForServerStateRequest{
-		UUID:         serverDetails.UUID,
-		DesiredState: upcloud.ServerStateStarted,
-		Timeout:      time.Minute * 25,
-	})
+	_, err = utils.WaitForServerState(ctx, meta, serverDetails.UUID, upcloud.ServerStateStarted, d.Timeout(schema.TimeoutCreate))
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if d.Get("power_state").(string) == upcloud.ServerStateStopped {
+		if err := utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, d.Id()), d.Timeout(schema.TimeoutCreate), meta); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if waitFor, ok := d.GetOk("wait_for.0"); ok {
+		server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: serverDetails.UUID})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		check, err := newReadinessCheck(waitFor.(map[string]interface{}), server)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		tflog.Info(ctx, "waiting for server to be ready", map[string]interface{}{"uuid": serverDetails.UUID, "type": check.checkType, "address": check.address, "port": check.port})
+		if err := check.wait(ctx); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return append(diags, resourceServerRead(ctx, d, meta)...)
 }
 
 func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	diags := diag.Diagnostics{}
 
 	r := &request.GetServerDetailsRequest{
@@ -502,7 +642,9 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 	_ = d.Set("cpu", server.CoreNumber)
 	_ = d.Set("mem", server.MemoryAmount)
 
-	_ = d.Set("labels", utils.LabelsSliceToMap(server.Labels))
+	if err := utils.SetLabels(d, meta, utils.LabelsSliceToMap(server.Labels)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	_ = d.Set("nic_model", server.NICModel)
 	_ = d.Set("timezone", server.Timezone)
@@ -510,6 +652,19 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 	_ = d.Set("metadata", server.Metadata.Bool())
 	_ = d.Set("plan", server.Plan)
 
+	_ = d.Set("remote_access_enabled", server.RemoteAccessEnabled.Bool())
+	_ = d.Set("remote_access_type", server.RemoteAccessType)
+	_ = d.Set("remote_access_host", server.RemoteAccessHost)
+	_ = d.Set("remote_access_port", server.RemoteAccessPort)
+	if server.RemoteAccessPassword != "" {
+		_ = d.Set("remote_access_password", server.RemoteAccessPassword)
+	}
+
+	// Servers in maintenance or error state are not in either of the manageable power states
+	if server.State == upcloud.ServerStateStarted || server.State == upcloud.ServerStateStopped {
+		_ = d.Set("power_state", server.State)
+	}
+
 	// XXX: server.Tags returns an empty slice rather than nil when it's empty
 	if len(server.Tags) > 0 {
 		_ = d.Set("tags", server.Tags)
@@ -530,39 +685,12 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 		_ = d.Set("simple_backup", []interface{}{simpleBackup})
 	}
 
-	networkInterfaces := []map[string]interface{}{}
-	var connIP string
-	for _, iface := range server.Networking.Interfaces {
-		ni := make(map[string]interface{})
-		ni["ip_address_family"] = iface.IPAddresses[0].Family
-		ni["ip_address"] = iface.IPAddresses[0].Address
-		if !iface.IPAddresses[0].Floating.Empty() {
-			ni["ip_address_floating"] = iface.IPAddresses[0].Floating.Bool()
-		}
-		ni["mac_address"] = iface.MAC
-		ni["network"] = iface.Network
-		ni["type"] = iface.Type
-		if !iface.Bootable.Empty() {
-			ni["bootable"] = iface.Bootable.Bool()
-		}
-		if !iface.SourceIPFiltering.Empty() {
-			ni["source_ip_filtering"] = iface.SourceIPFiltering.Bool()
-		}
-
-		networkInterfaces = append(networkInterfaces, ni)
-
-		if iface.Type == upcloud.NetworkTypePublic &&
-			iface.IPAddresses[0].Family == upcloud.IPAddressFamilyIPv4 {
-			connIP = iface.IPAddresses[0].Address
-		}
-	}
-
-	if err := d.Set("network_interface", networkInterfaces); err != nil {
+	if err := d.Set("network_interface", flattenNetworkInterfaces(managedNetworkInterfaces(d, server))); err != nil {
 		return diag.FromErr(err)
 	}
 
 	storageDevices := []interface{}{}
-	for _, serverStorage := range server.StorageDevices {
+	for _, serverStorage := range managedStorageDevices(d, server) {
 		// the template is managed within the server
 		if serverStorage.UUID == d.Get("template.0.id") {
 			_ = d.Set("template", []map[string]interface{}{{
@@ -577,6 +705,8 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 				// Those fields are not set anywhere in the API, they are just for internal TF use
 				"filesystem_autoresize":    d.Get("template.0.filesystem_autoresize"),
 				"delete_autoresize_backup": d.Get("template.0.delete_autoresize_backup"),
+				"reimage":                  d.Get("template.0.reimage"),
+				"rebuild_trigger":          d.Get("template.0.rebuild_trigger"),
 			}})
 		} else {
 			storageDevices = append(storageDevices, map[string]interface{}{
@@ -590,7 +720,7 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 
 	// Initialize the connection information.
 	d.SetConnInfo(map[string]string{
-		"host":     connIP,
+		"host":     publicIPv4Address(server),
 		"password": "",
 		"type":     "ssh",
 		"user":     "root",
@@ -600,7 +730,8 @@ func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, d.Id())()
 	diags := diag.Diagnostics{}
 
 	planHasChange := d.HasChange("plan")
@@ -618,12 +749,9 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	// Stop the server if the requested changes require it
-	if d.HasChanges("cpu", "mem", "timezone", "nic_model", "video_model", "template.0.size", "storage_devices", "network_interface") || planHasChange {
-		err := utils.VerifyServerStopped(ctx, request.StopServerRequest{
-			UUID: d.Id(),
-		},
-			meta,
-		)
+	rebuild := hasBootDiskRebuild(d)
+	if d.HasChanges(stopRequiringChanges...) || rebuild {
+		err := utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, d.Id()), d.Timeout(schema.TimeoutUpdate), meta)
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -634,8 +762,8 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	r.Hostname = d.Get("hostname").(string)
-	if d.HasChange("labels") {
-		r.Labels = buildLabels(d.Get("labels").(map[string]interface{}))
+	if d.HasChanges("labels", "labels_all") {
+		r.Labels = buildLabels(utils.LabelsWithDefaults(d, meta))
 	}
 
 	if attr, ok := d.GetOk("title"); ok {
@@ -658,6 +786,12 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 
 	r.Metadata = upcloud.FromBool(d.Get("metadata").(bool))
 
+	if d.HasChanges("remote_access_enabled", "remote_access_type", "remote_access_password") {
+		r.RemoteAccessEnabled = upcloud.FromBool(d.Get("remote_access_enabled").(bool))
+		r.RemoteAccessType = d.Get("remote_access_type").(string)
+		r.RemoteAccessPassword = d.Get("remote_access_password").(string)
+	}
+
 	if d.Get("firewall").(bool) {
 		r.Firewall = "on"
 	} else {
@@ -687,7 +821,10 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 						BackupRule: &upcloud.BackupRule{},
 					}
 
-					if _, err := client.ModifyStorage(ctx, r); err != nil {
+					if err := utils.RetryWhileStorageBusy(ctx, meta, r.UUID, d.Timeout(schema.TimeoutUpdate), func() error {
+						_, err := client.ModifyStorage(ctx, r)
+						return err
+					}); err != nil {
 						return diag.FromErr(err)
 					}
 				}
@@ -710,7 +847,10 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 	}
 
-	if _, err := client.ModifyServer(ctx, r); err != nil {
+	if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+		_, err := client.ModifyServer(ctx, r)
+		return err
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -734,8 +874,16 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 	}
 
+	// rebuild the boot disk, the new disk is created with the configured template title, size, backup rule and address
+	if rebuild {
+		diags = append(diags, rebuildBootDisk(ctx, d, meta, serverDetails)...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
 	// handle the template
-	if d.HasChanges("template.0.title", "template.0.size", "template.0.backup_rule") {
+	if !rebuild && d.HasChanges("template.0.title", "template.0.size", "template.0.backup_rule") {
 		template := d.Get("template.0").(map[string]interface{})
 		r := &request.ModifyStorageRequest{}
 
@@ -750,7 +898,11 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 			}
 		}
 
-		storageDetails, err := client.ModifyStorage(ctx, r)
+		var storageDetails *upcloud.StorageDetails
+		err := utils.RetryWhileStorageBusy(ctx, meta, r.UUID, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+			storageDetails, err = client.ModifyStorage(ctx, r)
+			return err
+		})
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -767,18 +919,24 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	// should reattach if address changed
-	if d.HasChange("template.0.address") {
+	if !rebuild && d.HasChange("template.0.address") {
 		o, n := d.GetChange("template.0.address")
-		if _, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
-			ServerUUID: d.Id(),
-			Address:    utils.StorageAddressFormat(o.(string)),
+		if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
+				ServerUUID: d.Id(),
+				Address:    utils.StorageAddressFormat(o.(string)),
+			})
+			return err
 		}); err != nil {
 			return diag.FromErr(err)
 		}
-		if _, err := client.AttachStorage(ctx, &request.AttachStorageRequest{
-			Address:     utils.StorageAddressFormat(n.(string)),
-			ServerUUID:  d.Id(),
-			StorageUUID: d.Get("template.0.id").(string),
+		if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := client.AttachStorage(ctx, &request.AttachStorageRequest{
+				Address:     utils.StorageAddressFormat(n.(string)),
+				ServerUUID:  d.Id(),
+				StorageUUID: d.Get("template.0.id").(string),
+			})
+			return err
 		}); err != nil {
 			return diag.FromErr(err)
 		}
@@ -795,18 +953,24 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 			if serverStorageDevice == nil {
 				continue
 			}
-			if _, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
-				ServerUUID: d.Id(),
-				Address:    serverStorageDevice.Address,
+			if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+				_, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
+					ServerUUID: d.Id(),
+					Address:    serverStorageDevice.Address,
+				})
+				return err
 			}); err != nil {
 				return diag.FromErr(err)
 			}
 
 			// Remove backup rule from the detached storage, if it was a result of simple backup setting
 			if _, ok := d.GetOk("simple_backup"); ok {
-				if _, err := client.ModifyStorage(ctx, &request.ModifyStorageRequest{
-					UUID:       serverStorageDevice.UUID,
-					BackupRule: &upcloud.BackupRule{},
+				if err := utils.RetryWhileStorageBusy(ctx, meta, serverStorageDevice.UUID, d.Timeout(schema.TimeoutUpdate), func() error {
+					_, err := client.ModifyStorage(ctx, &request.ModifyStorageRequest{
+						UUID:       serverStorageDevice.UUID,
+						BackupRule: &upcloud.BackupRule{},
+					})
+					return err
 				}); err != nil {
 					return diag.FromErr(err)
 				}
@@ -816,11 +980,14 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		// attach the storages that are new or have changed
 		for _, rawStorageDevice := range n.(*schema.Set).Difference(o.(*schema.Set)).List() {
 			storageDevice := rawStorageDevice.(map[string]interface{})
-			if _, err := client.AttachStorage(ctx, &request.AttachStorageRequest{
-				ServerUUID:  d.Id(),
-				Address:     utils.StorageAddressFormat(storageDevice["address"].(string)),
-				StorageUUID: storageDevice["storage"].(string),
-				Type:        storageDevice["type"].(string),
+			if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+				_, err := client.AttachStorage(ctx, &request.AttachStorageRequest{
+					ServerUUID:  d.Id(),
+					Address:     utils.StorageAddressFormat(storageDevice["address"].(string)),
+					StorageUUID: storageDevice["storage"].(string),
+					Type:        storageDevice["type"].(string),
+				})
+				return err
 			}); err != nil {
 				return diag.FromErr(err)
 			}
@@ -828,12 +995,16 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	if d.HasChange("network_interface") {
-		if err := reconfigureServerNetworkInterfaces(ctx, client, d); err != nil {
+		if err := reconfigureServerNetworkInterfaces(ctx, d, meta); err != nil {
 			return diag.FromErr(err)
 		}
 	}
 
-	if err := utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: d.Id(), Host: d.Get("host").(int)}, meta); err != nil {
+	if d.Get("power_state").(string) == upcloud.ServerStateStopped {
+		if err := utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, d.Id()), d.Timeout(schema.TimeoutUpdate), meta); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if err := utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: d.Id(), Host: d.Get("host").(int)}, d.Timeout(schema.TimeoutUpdate), meta); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -842,13 +1013,100 @@ func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	return diags
 }
 
+// resourceServerImport maps the storage devices of an existing server to template and storage_devices. The boot disk
+// of the server is imported as the template and the other storage devices are read as storage_devices. Arguments are set
+// to the given defaults to avoid in-place updates on the first apply.
+func resourceServerImport(ctx context.Context, d *schema.ResourceData, meta interface{}, defaults map[string]interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*config.Meta).Service
+
+	server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range defaults {
+		if err := d.Set(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	bootDisk := findBootDisk(server)
+	if bootDisk == nil {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	// The template the boot disk was created from cannot be determined, so use the UUID of the boot disk instead. See
+	// isImportedServer.
+	template := map[string]interface{}{
+		"id":                       bootDisk.UUID,
+		"storage":                  bootDisk.UUID,
+		"filesystem_autoresize":    false,
+		"delete_autoresize_backup": false,
+	}
+
+	if server.SimpleBackup == "no" {
+		storageDetails, err := client.GetStorageDetails(ctx, &request.GetStorageDetailsRequest{UUID: bootDisk.UUID})
+		if err != nil {
+			return nil, err
+		}
+		if br := storageDetails.BackupRule; br != nil && br.Retention > 0 {
+			template["backup_rule"] = []interface{}{map[string]interface{}{
+				"interval":  br.Interval,
+				"time":      br.Time,
+				"retention": br.Retention,
+			}}
+		}
+	}
+
+	if err := d.Set("template", []interface{}{template}); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// findBootDisk returns the storage device the server boots from, or nil if the server does not have any disks.
+func findBootDisk(server *upcloud.ServerDetails) *upcloud.ServerStorageDevice {
+	var bootDisk *upcloud.ServerStorageDevice
+	for i, device := range server.StorageDevices {
+		if device.Type != upcloud.StorageTypeDisk {
+			continue
+		}
+		if device.BootDisk == 1 {
+			return &server.StorageDevices[i]
+		}
+		if bootDisk == nil {
+			bootDisk = &server.StorageDevices[i]
+		}
+	}
+	return bootDisk
+}
+
+// isImportedServer reports whether the server was imported, i.e. the template storage in the state is the boot disk
+// itself instead of the template it was created from.
+func isImportedServer(d resourceChanges) bool {
+	id, _ := d.GetChange("template.0.id")
+	storage, _ := d.GetChange("template.0.storage")
+	return id.(string) != "" && id == storage
+}
+
+// suppressImportedServerDiff suppresses changes to arguments that are only used when creating the server and thus
+// cannot be determined for imported servers.
+func suppressImportedServerDiff(_, _, _ string, d *schema.ResourceData) bool {
+	return isImportedServer(d)
+}
+
 func resourceServerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, d.Id())()
 
 	var diags diag.Diagnostics
 
 	// Verify server is stopped before deletion
-	if err := utils.VerifyServerStopped(ctx, request.StopServerRequest{UUID: d.Id()}, meta); err != nil {
+	if err := utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, d.Id()), d.Timeout(schema.TimeoutDelete), meta); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -866,7 +1124,9 @@ func resourceServerDelete(ctx context.Context, d *schema.ResourceData, meta inte
 		UUID: d.Id(),
 	}
 	tflog.Info(ctx, "deleting server", map[string]interface{}{"uuid": d.Id()})
-	if err := client.DeleteServer(ctx, deleteServerRequest); err != nil {
+	if err := utils.RetryWhileServerBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutDelete), func() error {
+		return client.DeleteServer(ctx, deleteServerRequest)
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -877,7 +1137,9 @@ func resourceServerDelete(ctx context.Context, d *schema.ResourceData, meta inte
 			UUID: template["id"].(string),
 		}
 		tflog.Info(ctx, "deleting server storage", map[string]interface{}{"storage_uuid": deleteStorageRequest.UUID})
-		if err := client.DeleteStorage(ctx, deleteStorageRequest); err != nil {
+		if err := utils.RetryWhileStorageBusy(ctx, meta, deleteStorageRequest.UUID, d.Timeout(schema.TimeoutDelete), func() error {
+			return client.DeleteStorage(ctx, deleteStorageRequest)
+		}); err != nil {
 			return diag.FromErr(err)
 		}
 	}
@@ -898,8 +1160,8 @@ func buildServerOpts(ctx context.Context, d *schema.ResourceData, meta interface
 			r.Firewall = "off"
 		}
 	}
-	if attr, ok := d.GetOk("labels"); ok {
-		r.Labels = buildLabels(attr.(map[string]interface{}))
+	if labels := utils.LabelsWithDefaults(d, meta); len(labels) > 0 {
+		r.Labels = buildLabels(labels)
 	}
 	if attr, ok := d.GetOk("metadata"); ok {
 		if attr.(bool) {
@@ -908,6 +1170,11 @@ func buildServerOpts(ctx context.Context, d *schema.ResourceData, meta interface
 			r.Metadata = upcloud.False
 		}
 	}
+	r.RemoteAccessEnabled = upcloud.FromBool(d.Get("remote_access_enabled").(bool))
+	r.RemoteAccessType = d.Get("remote_access_type").(string)
+	if attr, ok := d.GetOk("remote_access_password"); ok {
+		r.RemoteAccessPassword = attr.(string)
+	}
 	if attr, ok := d.GetOk("cpu"); ok {
 		r.CoreNumber = attr.(int)
 	}
@@ -926,6 +1193,13 @@ func buildServerOpts(ctx context.Context, d *schema.ResourceData, meta interface
 	if attr, ok := d.GetOk("user_data"); ok {
 		r.UserData = attr.(string)
 	}
+	if attr, ok := d.GetOk("cloud_init.0"); ok {
+		userData, err := renderCloudConfig(attr.(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		r.UserData = userData
+	}
 	if attr, ok := d.GetOk("plan"); ok {
 		r.Plan = attr.(string)
 	}
@@ -960,22 +1234,10 @@ func buildServerOpts(ctx context.Context, d *schema.ResourceData, meta interface
 			serverStorageDevice.BackupRule = storage.BackupRule(attr.(map[string]interface{}))
 		}
 		if source := template["storage"].(string); source != "" {
-			// Assume template name is given and attempt map name to UUID
-			if _, err := uuid.ParseUUID(source); err != nil {
-				l, err := meta.(*service.Service).GetStorages(ctx, &request.GetStoragesRequest{
-					Type: upcloud.StorageTypeTemplate,
-				})
-				if err != nil {
-					return nil, err
-				}
-				for _, s := range l.Storages {
-					if s.Title == source {
-						source = s.UUID
-						break
-					}
-				}
+			source, err := resolveTemplateUUID(ctx, meta.(*config.Meta).Service, source)
+			if err != nil {
+				return nil, err
 			}
-
 			serverStorageDevice.Storage = source
 		}
 		r.StorageDevices = append(r.StorageDevices, serverStorageDevice)
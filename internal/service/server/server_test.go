@@ -1,12 +1,22 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 )
 
 func TestServerDefaultTitle(t *testing.T) {
@@ -64,3 +74,350 @@ func TestBuildLabels(t *testing.T) {
 	l := buildLabels(attr)
 	assert.Equal(t, &upcloud.LabelSlice{upcloud.Label{Key: "origin", Value: "unit-test"}}, l)
 }
+
+// testServerConfig returns the configuration of a server with the Ubuntu Server 22.04 template of the fake API and a
+// public network interface. Values in attrs are added to the configuration or override the defaults, and nil values
+// remove the default.
+func testServerConfig(hostname string, attrs map[string]interface{}) map[string]interface{} {
+	cfg := map[string]interface{}{
+		"hostname": hostname,
+		"zone":     "fi-hel1",
+		"plan":     "1xCPU-1GB",
+		"template": []interface{}{map[string]interface{}{
+			"storage": fakeapi.Ubuntu2204,
+		}},
+		"network_interface": []interface{}{map[string]interface{}{
+			"type": upcloud.NetworkTypePublic,
+		}},
+	}
+	for k, v := range attrs {
+		if v == nil {
+			delete(cfg, k)
+			continue
+		}
+		cfg[k] = v
+	}
+	return cfg
+}
+
+// testServerState returns the state of a server with a public network interface. Values in attrs are added to the
+// state attributes.
+func testServerState(hostname string, attrs map[string]string) *terraform.InstanceState {
+	state := &terraform.InstanceState{
+		ID: "00000000-0000-0000-0000-000000000000",
+		Attributes: map[string]string{
+			"id":                                    "00000000-0000-0000-0000-000000000000",
+			"hostname":                              hostname,
+			"zone":                                  "fi-hel1",
+			"network_interface.#":                   "1",
+			"network_interface.0.type":              upcloud.NetworkTypePublic,
+			"network_interface.0.ip_address_family": upcloud.IPAddressFamilyIPv4,
+			"network_interface.0.source_ip_filtering": "true",
+			"network_interface.0.bootable":            "false",
+		},
+		RawConfig: cty.ObjectVal(map[string]cty.Value{"zone": cty.StringVal("fi-hel1")}),
+	}
+	for k, v := range attrs {
+		state.Attributes[k] = v
+	}
+	return state
+}
+
+func TestResourceServer_powerState(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	r := ResourceServer()
+	d := schema.TestResourceDataRaw(t, r.Schema, testServerConfig("power-state.example.com", map[string]interface{}{
+		"power_state": upcloud.ServerStateStopped,
+	}))
+	require.False(t, r.CreateContext(ctx, d, meta).HasError())
+
+	server, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStopped, server.State)
+	assert.Equal(t, upcloud.ServerStateStopped, d.Get("power_state"))
+
+	require.NoError(t, d.Set("power_state", upcloud.ServerStateStarted))
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+
+	server, err = meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, server.State)
+	assert.Equal(t, upcloud.ServerStateStarted, d.Get("power_state"))
+}
+
+func TestResourceServer_remoteAccess(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	r := ResourceServer()
+	d := schema.TestResourceDataRaw(t, r.Schema, testServerConfig("remote-access.example.com", map[string]interface{}{
+		"remote_access_enabled":  true,
+		"remote_access_type":     upcloud.RemoteAccessTypeSPICE,
+		"remote_access_password": "hunter2",
+	}))
+	require.False(t, r.CreateContext(ctx, d, meta).HasError())
+	assert.Equal(t, true, d.Get("remote_access_enabled"))
+	assert.Equal(t, upcloud.RemoteAccessTypeSPICE, d.Get("remote_access_type"))
+	assert.Equal(t, "hunter2", d.Get("remote_access_password"))
+	assert.NotEmpty(t, d.Get("remote_access_host"))
+	assert.NotZero(t, d.Get("remote_access_port"))
+
+	// Disabling remote access removes the console endpoint
+	require.NoError(t, d.Set("remote_access_enabled", false))
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+	server, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	require.NoError(t, err)
+	assert.False(t, server.RemoteAccessEnabled.Bool())
+	assert.Empty(t, d.Get("remote_access_host"))
+
+	// Remote access enabled outside of Terraform is detected as drift
+	_, err = meta.Service.ModifyServer(ctx, &request.ModifyServerRequest{UUID: d.Id(), RemoteAccessEnabled: upcloud.True})
+	require.NoError(t, err)
+	require.False(t, r.ReadContext(ctx, d, meta).HasError())
+	assert.Equal(t, true, d.Get("remote_access_enabled"))
+}
+
+func TestResourceServer_rebuild(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	const (
+		jammy = fakeapi.Ubuntu2204
+		focal = fakeapi.Ubuntu2004
+	)
+	template := func(storage string, reimage bool, trigger string) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"storage":         storage,
+			"size":            25,
+			"reimage":         reimage,
+			"rebuild_trigger": trigger,
+		}}
+	}
+
+	r := ResourceServer()
+	d := schema.TestResourceDataRaw(t, r.Schema, testServerConfig("rebuild.example.com", map[string]interface{}{
+		"template": template(jammy, true, ""),
+	}))
+	require.False(t, r.CreateContext(ctx, d, meta).HasError())
+	oldDisk := d.Get("template.0.id").(string)
+	oldAddress := d.Get("template.0.address").(string)
+
+	planDiff := func(tmpl []interface{}) *terraform.InstanceDiff {
+		t.Helper()
+		state := d.State()
+		state.RawConfig = cty.ObjectVal(map[string]cty.Value{"zone": cty.StringVal("fi-hel1")})
+		diff, err := r.SimpleDiff(ctx, state, terraform.NewResourceConfigRaw(testServerConfig("rebuild.example.com", map[string]interface{}{
+			"template": tmpl,
+		})), meta)
+		require.NoError(t, err)
+		require.NotNil(t, diff)
+		return diff
+	}
+
+	// Changing the template rebuilds the boot disk in place
+	d, err := schema.InternalMap(r.Schema).Data(d.State(), planDiff(template(focal, true, "v1")))
+	require.NoError(t, err)
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+
+	server, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: d.Id()})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, server.State)
+	assert.Nil(t, server.StorageDevice(oldDisk))
+	newDisk := server.StorageDevice(d.Get("template.0.id").(string))
+	require.NotNil(t, newDisk)
+	assert.NotEqual(t, oldDisk, newDisk.UUID)
+	assert.Equal(t, 25, newDisk.Size)
+	assert.Equal(t, oldAddress, utils.StorageAddressFormat(newDisk.Address))
+	assert.Equal(t, focal, d.Get("template.0.storage"))
+	assert.Equal(t, true, d.Get("template.0.reimage"))
+	assert.Equal(t, "v1", d.Get("template.0.rebuild_trigger"))
+	_, err = meta.Service.GetStorageDetails(ctx, &request.GetStorageDetailsRequest{UUID: oldDisk})
+	assert.Error(t, err)
+
+	// Changing the template replaces the server, unless reimage is enabled
+	assert.True(t, planDiff(template(jammy, false, "v1")).RequiresNew())
+	assert.False(t, planDiff(template(jammy, true, "v1")).RequiresNew())
+	assert.False(t, planDiff(template(focal, false, "v2")).RequiresNew())
+}
+
+func TestResourceServer_allowStopForUpdate(t *testing.T) {
+	diff := func(powerState string, allowStop bool) error {
+		state := testServerState("stop.example.com", map[string]string{
+			"cpu":         "1",
+			"mem":         "1024",
+			"power_state": powerState,
+		})
+		cfg := testServerConfig("stop.example.com", map[string]interface{}{
+			"plan":                  nil,
+			"template":              nil,
+			"cpu":                   2,
+			"mem":                   1024,
+			"power_state":           powerState,
+			"allow_stop_for_update": allowStop,
+		})
+		_, err := ResourceServer().SimpleDiff(context.Background(), state, terraform.NewResourceConfigRaw(cfg), &config.Meta{})
+		return err
+	}
+
+	err := diff(upcloud.ServerStateStarted, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changing cpu requires stopping the server")
+
+	assert.NoError(t, diff(upcloud.ServerStateStarted, true))
+	assert.NoError(t, diff(upcloud.ServerStateStopped, false))
+}
+
+func TestResourceServer_deletionProtection(t *testing.T) {
+	state := testServerState("protected.example.com", map[string]string{
+		"plan":                "1xCPU-1GB",
+		"deletion_protection": "true",
+		"template.#":          "1",
+		"template.0.id":       "01000000-0000-4000-8000-000000000000",
+		"template.0.storage":  fakeapi.Ubuntu2204,
+	})
+	diff := func(zone, template string) error {
+		_, err := ResourceServer().SimpleDiff(context.Background(), state, terraform.NewResourceConfigRaw(testServerConfig("protected.example.com", map[string]interface{}{
+			"zone":                zone,
+			"deletion_protection": false,
+			"template":            []interface{}{map[string]interface{}{"storage": template}},
+		})), &config.Meta{})
+		return err
+	}
+
+	err := diff("de-fra1", fakeapi.Ubuntu2204)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changing zone requires replacing the resource")
+	err = diff("fi-hel1", fakeapi.Ubuntu2004)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changing template.0.storage requires replacing the resource")
+
+	// Disabling the protection is allowed, as it does not replace the server
+	require.NoError(t, diff("fi-hel1", fakeapi.Ubuntu2204))
+
+	d := ResourceServer().Data(state)
+	diags := resourceServerDelete(context.Background(), d, &config.Meta{})
+	require.True(t, diags.HasError())
+	assert.Equal(t, "deletion protection is enabled", diags[0].Summary)
+}
+
+func TestResourceServer_import(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	server := fakeapi.CreateServer(t, meta.Service, "legacy.example.com", func(r *request.CreateServerRequest) {
+		r.Title = "legacy.example.com (managed by terraform)"
+		r.StorageDevices = append(r.StorageDevices, request.CreateServerStorageDevice{
+			Action: request.CreateServerStorageDeviceActionCreate,
+			Title:  "legacy-data",
+			Size:   50,
+		})
+	})
+	bootDisk, dataDisk := server.StorageDevices[0], server.StorageDevices[1]
+
+	r := ResourceServer()
+	d := r.Data(nil)
+	d.SetId(server.UUID)
+	imported, err := r.Importer.StateContext(ctx, d, meta)
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	d = imported[0]
+	require.False(t, r.ReadContext(ctx, d, meta).HasError())
+
+	assert.Equal(t, bootDisk.UUID, d.Get("template.0.id"))
+	assert.Equal(t, 25, d.Get("template.0.size"))
+	assert.Equal(t, upcloud.StopTypeSoft, d.Get("stop_type"))
+	storageDevices := d.Get("storage_devices").(*schema.Set).List()
+	require.Len(t, storageDevices, 1)
+	assert.Equal(t, dataDisk.UUID, storageDevices[0].(map[string]interface{})["storage"])
+
+	// Configuration of the server does not replace the imported server
+	state := d.State()
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{"zone": cty.StringVal("fi-hel1")})
+	storageDevicesConfig := []interface{}{map[string]interface{}{
+		"storage": dataDisk.UUID,
+		"address": "virtio",
+		"type":    upcloud.StorageTypeDisk,
+	}}
+	cfg := terraform.NewResourceConfigRaw(testServerConfig("legacy.example.com", map[string]interface{}{
+		"template": []interface{}{map[string]interface{}{
+			"storage": "Ubuntu Server 22.04 LTS (Jammy Jellyfish)",
+			"size":    25,
+		}},
+		"storage_devices": storageDevicesConfig,
+		"login": []interface{}{map[string]interface{}{
+			"user": "admin",
+			"keys": []interface{}{"ssh-ed25519 AAAA"},
+		}},
+		"user_data": "#!/bin/sh\necho hello",
+	}))
+	diff, err := r.SimpleDiff(ctx, state, cfg, meta)
+	require.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff: %v", diff)
+
+	diff, err = r.SimpleDiff(ctx, state, terraform.NewResourceConfigRaw(testServerConfig("legacy.example.com", map[string]interface{}{
+		"template": []interface{}{map[string]interface{}{
+			"storage": "Ubuntu Server 22.04 LTS (Jammy Jellyfish)",
+			"size":    25,
+		}},
+		"storage_devices": storageDevicesConfig,
+		"cloud_init": []interface{}{map[string]interface{}{
+			"packages": []interface{}{"nginx"},
+			"runcmd":   []interface{}{"systemctl enable --now nginx"},
+		}},
+	})), meta)
+	require.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff: %v", diff)
+
+	// Changes to the template of imported servers cannot be detected, so reimage fails the plan and rebuild_trigger is
+	// used instead
+	planTemplate := func(trigger string, reimage bool) (*terraform.InstanceDiff, error) {
+		state := d.State()
+		state.RawConfig = cty.ObjectVal(map[string]cty.Value{
+			"zone": cty.StringVal("fi-hel1"),
+			"template": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"storage": cty.StringVal("Ubuntu Server 20.04 LTS (Focal Fossa)"),
+			})}),
+		})
+		return r.SimpleDiff(ctx, state, terraform.NewResourceConfigRaw(testServerConfig("legacy.example.com", map[string]interface{}{
+			"template": []interface{}{map[string]interface{}{
+				"storage":         "Ubuntu Server 20.04 LTS (Focal Fossa)",
+				"size":            25,
+				"reimage":         reimage,
+				"rebuild_trigger": trigger,
+			}},
+			"storage_devices": storageDevicesConfig,
+		})), meta)
+	}
+	_, err = planTemplate("", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use rebuild_trigger")
+
+	diff, err = planTemplate("v1", false)
+	require.NoError(t, err)
+	d, err = schema.InternalMap(r.Schema).Data(d.State(), diff)
+	require.NoError(t, err)
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+	diff, err = planTemplate("v2", false)
+	require.NoError(t, err)
+	assert.False(t, diff.RequiresNew())
+	d, err = schema.InternalMap(r.Schema).Data(d.State(), diff)
+	require.NoError(t, err)
+	diags := r.UpdateContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+
+	details, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Nil(t, details.StorageDevice(bootDisk.UUID))
+	assert.NotNil(t, details.StorageDevice(d.Get("template.0.id").(string)))
+	assert.NotNil(t, details.StorageDevice(dataDisk.UUID))
+	assert.Equal(t, "Ubuntu Server 20.04 LTS (Focal Fossa)", d.Get("template.0.storage"))
+
+	// Changing the template of a server created by the provider still replaces the server
+	state.Attributes["template.0.storage"] = fakeapi.Ubuntu2204
+	diff, err = r.SimpleDiff(ctx, state, cfg, meta)
+	require.NoError(t, err)
+	require.NotNil(t, diff)
+	assert.True(t, diff.RequiresNew())
+}
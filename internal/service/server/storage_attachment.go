@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func ResourceServerStorageAttachment() *schema.Resource {
+	return &schema.Resource{
+		Description: `This resource attaches a storage to an existing server. The server is stopped while the storage is attached or detached, and started again afterwards, if it was running. The server is stopped as defined by ` + "`stop_type`" + ` and ` + "`graceful_shutdown_timeout`" + `. Set ` + "`allow_stop_for_update`" + ` to ` + "`false`" + ` to fail instead of stopping a running server.
+
+Changing ` + "`server_id`" + ` detaches the storage from the current server and attaches it to the new one, which allows moving storages between servers without modifying the ` + "`upcloud_server`" + ` resources. Set ` + "`ignore_external_storage_devices`" + ` to ` + "`true`" + ` in the ` + "`upcloud_server`" + ` resource to prevent it from detaching storages managed with this resource.`,
+		CreateContext: resourceServerStorageAttachmentCreate,
+		ReadContext:   resourceServerStorageAttachmentRead,
+		UpdateContext: resourceServerStorageAttachmentUpdate,
+		DeleteContext: resourceServerStorageAttachmentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 10),
+			Delete: schema.DefaultTimeout(time.Minute * 10),
+		},
+		Schema: utils.JoinSchemas(map[string]*schema.Schema{
+			"server_id": {
+				Description: "The UUID of the server the storage is attached to.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"storage": {
+				Description: "The UUID of the storage to attach.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"address": {
+				Description: "The device address the storage is attached to. Specify only the bus name (ide/scsi/virtio) to auto-select next available address from that bus.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					"scsi",
+					"virtio",
+					"ide",
+				}, false)),
+			},
+			"address_position": {
+				Description: "The full device address the storage is attached to, e.g. `virtio:1`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"type": {
+				Description: "The device type the storage is attached as (one of `disk` or `cdrom`).",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     upcloud.StorageTypeDisk,
+				ForceNew:    true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.StorageTypeDisk,
+					upcloud.StorageTypeCDROM,
+				}, false)),
+			},
+		}, utils.ServerStopSchema("to attach or detach the storage")),
+	}
+}
+
+func resourceServerStorageAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID := d.Get("server_id").(string)
+	storageUUID := d.Get("storage").(string)
+	defer utils.LockServers(ctx, meta, serverUUID)()
+
+	r := &request.AttachStorageRequest{
+		ServerUUID:  serverUUID,
+		StorageUUID: storageUUID,
+		Type:        d.Get("type").(string),
+		Address:     d.Get("address").(string),
+	}
+	err := withServerStopped(ctx, d, serverUUID, d.Timeout(schema.TimeoutCreate), meta, func() error {
+		return utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutCreate), func() error {
+			_, err := client.AttachStorage(ctx, r)
+			return err
+		})
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(marshalStorageAttachmentID(serverUUID, storageUUID))
+	tflog.Info(ctx, "storage attached", map[string]interface{}{"server_uuid": serverUUID, "storage_uuid": storageUUID})
+
+	return resourceServerStorageAttachmentRead(ctx, d, meta)
+}
+
+func resourceServerStorageAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID, storageUUID, err := unmarshalStorageAttachmentID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: serverUUID})
+	if err != nil {
+		return utils.HandleResourceError(d.Id(), d, err)
+	}
+
+	device := server.StorageDevice(storageUUID)
+	if device == nil {
+		tflog.Warn(ctx, "storage is not attached to the server, removing it from the state", map[string]interface{}{"server_uuid": serverUUID, "storage_uuid": storageUUID})
+		d.SetId("")
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"server_id":        serverUUID,
+		"storage":          device.UUID,
+		"address":          utils.StorageAddressFormat(device.Address),
+		"address_position": device.Address,
+		"type":             device.Type,
+	}
+	for k, v := range data {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+// resourceServerStorageAttachmentUpdate only stores the changes, as all the other arguments force a new resource and the
+// stop arguments only affect how the server is stopped in later operations.
+func resourceServerStorageAttachmentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceServerStorageAttachmentRead(ctx, d, meta)
+}
+
+func resourceServerStorageAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*config.Meta).Service
+	serverUUID, storageUUID, err := unmarshalStorageAttachmentID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer utils.LockServers(ctx, meta, serverUUID)()
+
+	server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: serverUUID})
+	if err != nil {
+		return utils.HandleResourceError(d.Id(), d, err)
+	}
+
+	// The storage might have already been detached, e.g. when it has been moved to another server
+	device := server.StorageDevice(storageUUID)
+	if device == nil {
+		tflog.Info(ctx, "storage is not attached to the server, nothing to detach", map[string]interface{}{"server_uuid": serverUUID, "storage_uuid": storageUUID})
+		return nil
+	}
+
+	err = withServerStopped(ctx, d, serverUUID, d.Timeout(schema.TimeoutDelete), meta, func() error {
+		return utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutDelete), func() error {
+			_, err := client.DetachStorage(ctx, &request.DetachStorageRequest{
+				ServerUUID: serverUUID,
+				Address:    device.Address,
+			})
+			return err
+		})
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	tflog.Info(ctx, "storage detached", map[string]interface{}{"server_uuid": serverUUID, "storage_uuid": storageUUID})
+	return nil
+}
+
+func marshalStorageAttachmentID(serverUUID, storageUUID string) string {
+	return fmt.Sprintf("%s/%s", serverUUID, storageUUID)
+}
+
+func unmarshalStorageAttachmentID(id string) (serverUUID, storageUUID string, err error) {
+	serverUUID, storageUUID, ok := strings.Cut(id, "/")
+	if !ok || serverUUID == "" || storageUUID == "" {
+		return "", "", fmt.Errorf("invalid storage attachment ID '%s', expected format <server UUID>/<storage UUID>", id)
+	}
+	return serverUUID, storageUUID, nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+)
+
+func TestResourceServerStorageAttachment(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	blue := fakeapi.CreateServer(t, meta.Service, "blue.example.com")
+	green := fakeapi.CreateServer(t, meta.Service, "green.example.com")
+	require.NoError(t, utils.VerifyServerStopped(ctx, request.StopServerRequest{UUID: green.UUID, StopType: upcloud.StopTypeHard}, time.Minute, meta))
+
+	storage, err := meta.Service.CreateStorage(ctx, &request.CreateStorageRequest{
+		Zone:  "fi-hel1",
+		Title: "data",
+		Size:  10,
+	})
+	require.NoError(t, err)
+
+	r := ResourceServerStorageAttachment()
+	attach := func(serverUUID string) *schema.ResourceData {
+		t.Helper()
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"server_id": serverUUID,
+			"storage":   storage.UUID,
+			"address":   "virtio",
+		})
+		diags := r.CreateContext(ctx, d, meta)
+		require.False(t, diags.HasError(), diags)
+		return d
+	}
+	getServer := func(uuid string) *upcloud.ServerDetails {
+		t.Helper()
+		details, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+		require.NoError(t, err)
+		return details
+	}
+
+	d := attach(blue.UUID)
+	assert.Equal(t, blue.UUID+"/"+storage.UUID, d.Id())
+	assert.Equal(t, "virtio", d.Get("address"))
+	assert.Equal(t, "virtio:1", d.Get("address_position"))
+	assert.Equal(t, upcloud.StorageTypeDisk, d.Get("type"))
+
+	// Server is started again after attaching the storage
+	details := getServer(blue.UUID)
+	assert.Equal(t, upcloud.ServerStateStarted, details.State)
+	require.NotNil(t, details.StorageDevice(storage.UUID))
+
+	// Server resource ignores the storage, if configured to do so
+	sd := ResourceServer().TestResourceData()
+	require.NoError(t, sd.Set("template", []interface{}{map[string]interface{}{"id": details.StorageDevices[0].UUID}}))
+	assert.Len(t, managedStorageDevices(sd, details), 2)
+	require.NoError(t, sd.Set("ignore_external_storage_devices", true))
+	assert.Len(t, managedStorageDevices(sd, details), 1)
+
+	// Imported attachment is read from the server
+	imported := r.Data(nil)
+	imported.SetId(d.Id())
+	diags := r.ReadContext(ctx, imported, meta)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, blue.UUID, imported.Get("server_id"))
+	assert.Equal(t, storage.UUID, imported.Get("storage"))
+
+	// Move the storage to another server
+	diags = r.DeleteContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+	moved := attach(green.UUID)
+	assert.Nil(t, getServer(blue.UUID).StorageDevice(storage.UUID))
+	details = getServer(green.UUID)
+	assert.NotNil(t, details.StorageDevice(storage.UUID))
+	assert.Equal(t, upcloud.ServerStateStopped, details.State)
+
+	// Attachment is removed from the state, if the storage is no longer attached to the server
+	diags = r.ReadContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+	assert.Empty(t, d.Id())
+
+	// Detaching a storage that has already been moved does nothing
+	d.SetId(marshalStorageAttachmentID(blue.UUID, storage.UUID))
+	diags = r.DeleteContext(ctx, d, meta)
+	require.False(t, diags.HasError(), diags)
+	assert.NotNil(t, getServer(green.UUID).StorageDevice(storage.UUID))
+
+	diags = r.DeleteContext(ctx, moved, meta)
+	require.False(t, diags.HasError(), diags)
+	assert.Nil(t, getServer(green.UUID).StorageDevice(storage.UUID))
+
+	_, _, err = unmarshalStorageAttachmentID("invalid")
+	assert.Error(t, err)
+}
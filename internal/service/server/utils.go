@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -60,3 +62,58 @@ func sliceToMap(input []string) map[string]bool {
 	}
 	return output
 }
+
+// schemaDefaults returns the default values of the top-level arguments of s.
+func schemaDefaults(s map[string]*schema.Schema) map[string]interface{} {
+	defaults := make(map[string]interface{})
+	for k, v := range s {
+		if v.Default != nil {
+			defaults[k] = v.Default
+		}
+	}
+	return defaults
+}
+
+// managedStorageDevices returns the storage devices of the server that are managed in the server resource. If
+// ignore_external_storage_devices is enabled, only the template and the storages defined in storage_devices are
+// returned.
+func managedStorageDevices(d *schema.ResourceData, server *upcloud.ServerDetails) upcloud.ServerStorageDeviceSlice {
+	if !d.Get("ignore_external_storage_devices").(bool) {
+		return server.StorageDevices
+	}
+
+	managed := map[string]bool{d.Get("template.0.id").(string): true}
+	for _, device := range d.Get("storage_devices").(*schema.Set).List() {
+		managed[device.(map[string]interface{})["storage"].(string)] = true
+	}
+
+	devices := make(upcloud.ServerStorageDeviceSlice, 0, len(server.StorageDevices))
+	for _, device := range server.StorageDevices {
+		if managed[device.UUID] {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// resolveTemplateUUID returns the UUID of the template with the given UUID or name. If no template matches the name,
+// the name is returned as is.
+func resolveTemplateUUID(ctx context.Context, svc *service.Service, source string) (string, error) {
+	if _, err := uuid.ParseUUID(source); err == nil {
+		return source, nil
+	}
+
+	// Assume template name is given and attempt map name to UUID
+	l, err := svc.GetStorages(ctx, &request.GetStoragesRequest{
+		Type: upcloud.StorageTypeTemplate,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, s := range l.Storages {
+		if s.Title == source {
+			return s.UUID, nil
+		}
+	}
+	return source, nil
+}
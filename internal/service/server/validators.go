@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/validator"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -86,7 +88,7 @@ func validateZone(ctx context.Context, service *service.Service, zone string) er
 func validateTagsChange(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 	oldTags, newTags := d.GetChange("tags")
 	if tagsHasChange(oldTags, newTags) {
-		client := meta.(*service.Service)
+		client := meta.(*config.Meta).Service
 
 		if isSubaccount, err := isProviderAccountSubaccount(ctx, client); err != nil || isSubaccount {
 			if err != nil {
@@ -112,3 +114,34 @@ func validateTagsChange(ctx context.Context, d *schema.ResourceDiff, meta interf
 
 	return nil
 }
+
+// validateStopForUpdate prevents planning changes that would stop a started server, unless allow_stop_for_update is enabled.
+func validateStopForUpdate(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || d.Get("allow_stop_for_update").(bool) {
+		return nil
+	}
+
+	// The server is not running during the update, if it is stopped or will be stopped
+	oldState, newState := d.GetChange("power_state")
+	if oldState.(string) == upcloud.ServerStateStopped || newState.(string) == upcloud.ServerStateStopped {
+		return nil
+	}
+
+	var changes []string
+	for _, key := range stopRequiringChanges {
+		if d.HasChange(key) {
+			changes = append(changes, key)
+		}
+	}
+	if hasBootDiskRebuild(d) {
+		if d.HasChange("template.0.storage") {
+			changes = append(changes, "template.0.storage")
+		} else {
+			changes = append(changes, "template.0.rebuild_trigger")
+		}
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("changing %s requires stopping the server. Set allow_stop_for_update to true to allow stopping the server during the update", strings.Join(changes, ", "))
+	}
+	return nil
+}
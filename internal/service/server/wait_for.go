@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	readinessCheckTCP  = "tcp"
+	readinessCheckHTTP = "http"
+)
+
+func waitForSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Block describing a readiness check that is run after the server has been created. " +
+			"Creating the server completes only after the server responds to the check, e.g. when cloud-init has finished installing a service. " +
+			"If the server does not respond within `timeout`, the server is marked as tainted. The check is not run for servers created with `power_state` set to `stopped`. " +
+			"Changes to the check after the server has been created are ignored.",
+		Type:             schema.TypeList,
+		Optional:         true,
+		MaxItems:         1,
+		DiffSuppressFunc: suppressDiffAfterCreate,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": {
+					Description: "The type of the check, either `tcp` or `http`. The `tcp` check waits until the port accepts connections and the `http` check waits until `path` responds with `expected_status`.",
+					Type:        schema.TypeString,
+					Required:    true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+						readinessCheckTCP,
+						readinessCheckHTTP,
+					}, false)),
+				},
+				"port": {
+					Description:      "The port to check",
+					Type:             schema.TypeInt,
+					Required:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IsPortNumber),
+				},
+				"path": {
+					Description:      "The path of the HTTP request. Only used with `http` check.",
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "/",
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringMatch(regexp.MustCompile("^/"), "path must start with /")),
+				},
+				"expected_status": {
+					Description:      "The expected HTTP response status code. Only used with `http` check.",
+					Type:             schema.TypeInt,
+					Optional:         true,
+					Default:          http.StatusOK,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntBetween(100, 599)),
+				},
+				"network_interface_index": {
+					Description:      "The index of the network interface whose IP address is checked. The first network interface has index 1.",
+					Type:             schema.TypeInt,
+					Optional:         true,
+					Default:          1,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				},
+				"timeout": {
+					Description:      "The maximum time to wait for the server to respond, e.g. `10m`",
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "5m",
+					ValidateDiagFunc: validateDuration,
+				},
+				"interval": {
+					Description:      "The time to wait between the checks, e.g. `10s`",
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "5s",
+					ValidateDiagFunc: validateDuration,
+				},
+			},
+		},
+	}
+}
+
+// minReadinessCheckTimeout is the minimum time a single check may take, so that short intervals do not cause the checks
+// to fail before a slow server has had time to respond.
+const minReadinessCheckTimeout = 2 * time.Second
+
+// suppressDiffAfterCreate suppresses changes to the readiness check of existing servers, as the check is only run when
+// creating the server.
+func suppressDiffAfterCreate(_, _, _ string, d *schema.ResourceData) bool {
+	return d.Id() != ""
+}
+
+var validateDuration = validation.ToDiagFunc(func(v interface{}, k string) ([]string, []error) {
+	if d, err := time.ParseDuration(v.(string)); err != nil || d <= 0 {
+		return nil, []error{fmt.Errorf("expected %s to be a positive duration, e.g. `30s` or `5m`, got %s", k, v)}
+	}
+	return nil, nil
+})
+
+type readinessCheck struct {
+	checkType      string
+	address        string
+	port           int
+	path           string
+	expectedStatus int
+	timeout        time.Duration
+	interval       time.Duration
+}
+
+// newReadinessCheck builds the readiness check defined in the wait_for block for the given server.
+func newReadinessCheck(v map[string]interface{}, server *upcloud.ServerDetails) (*readinessCheck, error) {
+	c := &readinessCheck{
+		checkType:      v["type"].(string),
+		port:           v["port"].(int),
+		path:           v["path"].(string),
+		expectedStatus: v["expected_status"].(int),
+	}
+
+	var err error
+	if c.timeout, err = time.ParseDuration(v["timeout"].(string)); err != nil {
+		return nil, err
+	}
+	if c.interval, err = time.ParseDuration(v["interval"].(string)); err != nil {
+		return nil, err
+	}
+
+	index := v["network_interface_index"].(int)
+	for _, iface := range server.Networking.Interfaces {
+		if iface.Index == index && len(iface.IPAddresses) > 0 {
+			c.address = iface.IPAddresses[0].Address
+		}
+	}
+	if c.address == "" {
+		return nil, fmt.Errorf("network interface %d of the server does not have an IP address", index)
+	}
+	return c, nil
+}
+
+// wait runs the check until it succeeds or the timeout of the check is reached.
+func (c *readinessCheck) wait(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	target := net.JoinHostPort(c.address, strconv.Itoa(c.port))
+	for {
+		err := c.check(ctx, target)
+		if err == nil {
+			return nil
+		}
+		tflog.Debug(ctx, "server is not ready yet", map[string]interface{}{"type": c.checkType, "target": target, "error": err.Error()})
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("server did not pass %s check on %s within %s: %w", c.checkType, target, c.timeout, err)
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+// check runs the check once. A single check takes at most the interval of the check or minReadinessCheckTimeout,
+// whichever is longer, and never longer than the remaining time of ctx.
+func (c *readinessCheck) check(ctx context.Context, target string) error {
+	timeout := c.interval
+	if timeout < minReadinessCheckTimeout {
+		timeout = minReadinessCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if c.checkType == readinessCheckTCP {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", target, c.path), nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != c.expectedStatus {
+		return fmt.Errorf("expected status %d, got %d", c.expectedStatus, res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+)
+
+func TestReadinessCheck(t *testing.T) {
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := requests.Add(1); r.URL.Path != "/healthz" || n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host, p, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(p)
+	require.NoError(t, err)
+
+	server := &upcloud.ServerDetails{}
+	server.Networking.Interfaces = upcloud.ServerInterfaceSlice{
+		{Index: 1, IPAddresses: upcloud.IPAddressSlice{{Address: "192.0.2.1", Family: upcloud.IPAddressFamilyIPv4}}},
+		{Index: 2, IPAddresses: upcloud.IPAddressSlice{{Address: host, Family: upcloud.IPAddressFamilyIPv4}}},
+	}
+	newCheck := func(checkType string, port int, timeout string) *readinessCheck {
+		t.Helper()
+		c, err := newReadinessCheck(map[string]interface{}{
+			"type":                    checkType,
+			"port":                    port,
+			"path":                    "/healthz",
+			"expected_status":         http.StatusOK,
+			"network_interface_index": 2,
+			"timeout":                 timeout,
+			"interval":                "10ms",
+		}, server)
+		require.NoError(t, err)
+		return c
+	}
+
+	// HTTP check is retried until the expected status is returned
+	assert.NoError(t, newCheck(readinessCheckHTTP, port, "5s").wait(ctx))
+	assert.Equal(t, int32(3), requests.Load())
+
+	assert.NoError(t, newCheck(readinessCheckTCP, port, "5s").wait(ctx))
+
+	// Single check may take longer than the interval between the checks
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	slowURL, err := url.Parse(slow.URL)
+	require.NoError(t, err)
+	slowPort, err := strconv.Atoi(slowURL.Port())
+	require.NoError(t, err)
+	assert.NoError(t, newCheck(readinessCheckHTTP, slowPort, "5s").wait(ctx))
+
+	// Check fails when nothing listens on the port
+	l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	require.NoError(t, err)
+	closedPort := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	assert.Error(t, newCheck(readinessCheckTCP, closedPort, "100ms").wait(ctx))
+
+	_, err = newReadinessCheck(map[string]interface{}{
+		"type":                    readinessCheckTCP,
+		"port":                    22,
+		"path":                    "/",
+		"expected_status":         http.StatusOK,
+		"network_interface_index": 3,
+		"timeout":                 "5m",
+		"interval":                "5s",
+	}, server)
+	assert.Error(t, err)
+}
+
+func TestResourceServer_waitForChanges(t *testing.T) {
+	// Readiness check is only run when creating the server, so changing it does not affect existing servers
+	state := testServerState("wait-for.example.com", map[string]string{
+		"wait_for.#":                         "1",
+		"wait_for.0.type":                    readinessCheckTCP,
+		"wait_for.0.port":                    "22",
+		"wait_for.0.path":                    "/",
+		"wait_for.0.expected_status":         "200",
+		"wait_for.0.network_interface_index": "1",
+		"wait_for.0.timeout":                 "5m",
+		"wait_for.0.interval":                "5s",
+	})
+	cfg := terraform.NewResourceConfigRaw(testServerConfig("wait-for.example.com", map[string]interface{}{
+		"wait_for": []interface{}{map[string]interface{}{
+			"type":    readinessCheckHTTP,
+			"port":    80,
+			"timeout": "10m",
+		}},
+	}))
+	diff, err := ResourceServer().SimpleDiff(context.Background(), state, cfg, &config.Meta{})
+	require.NoError(t, err)
+	require.NotNil(t, diff)
+	for k := range diff.Attributes {
+		assert.NotContains(t, k, "wait_for")
+	}
+}
@@ -0,0 +1,130 @@
+package servergroup
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+)
+
+func memberSelectorSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Block describing the servers that are members of this group. The selector is evaluated when planning: " +
+			"servers that match the selector are added to the group and servers that no longer match it are removed from the group. " +
+			"This allows servers managed in other configurations to join the group by setting the matching labels or tag.",
+		Type:          schema.TypeList,
+		Optional:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"members"},
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"labels": {
+					Description:  "Labels that the servers must have. A server must have all of the labels with the given values to match.",
+					Type:         schema.TypeMap,
+					Optional:     true,
+					Elem:         &schema.Schema{Type: schema.TypeString},
+					AtLeastOneOf: []string{"member_selector.0.labels", "member_selector.0.tag"},
+				},
+				"tag": {
+					Description:  "Tag that the servers must have",
+					Type:         schema.TypeString,
+					Optional:     true,
+					AtLeastOneOf: []string{"member_selector.0.labels", "member_selector.0.tag"},
+				},
+			},
+		},
+	}
+}
+
+// planMembers is a CustomizeDiff function that plans the members of the group to be the servers matching
+// member_selector. As members is also computed, members removed from the configuration are planned to be removed
+// from the group when the selector is not used.
+func planMembers(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	selector, ok := d.GetOk("member_selector")
+	if !ok {
+		if membersConfigured(d) || (d.Id() != "" && d.Get("members").(*schema.Set).Len() == 0) {
+			return nil
+		}
+		return d.SetNew("members", []interface{}{})
+	}
+	if !d.NewValueKnown("member_selector") {
+		return d.SetNewComputed("members")
+	}
+
+	m, ok := selector.([]interface{})[0].(map[string]interface{})
+	if !ok {
+		return errors.New("member_selector must define labels or tag")
+	}
+	members, err := selectMembers(ctx, meta.(*config.Meta).Service, m)
+	if err != nil {
+		return err
+	}
+	if d.Get("members").(*schema.Set).Equal(schema.NewSet(schema.HashString, members)) {
+		return nil
+	}
+	return d.SetNew("members", members)
+}
+
+// membersConfigured reports whether members is set in the configuration.
+func membersConfigured(d *schema.ResourceDiff) bool {
+	rawConfig := d.GetRawConfig()
+	if !rawConfig.IsKnown() || rawConfig.IsNull() || !rawConfig.Type().HasAttribute("members") {
+		return false
+	}
+	return !rawConfig.GetAttr("members").IsNull()
+}
+
+// selectMembers returns the UUIDs of the servers that match the member_selector block.
+func selectMembers(ctx context.Context, svc *service.Service, selector map[string]interface{}) ([]interface{}, error) {
+	servers, err := svc.GetServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, _ := selector["tag"].(string)
+	labels, _ := selector["labels"].(map[string]interface{})
+	members := make([]interface{}, 0)
+	for _, server := range servers.Servers {
+		if tag != "" && !hasTag(server, tag) {
+			continue
+		}
+		if len(labels) > 0 {
+			// Labels are only included in the server details
+			details, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+			if err != nil {
+				return nil, err
+			}
+			if !hasLabels(details.Labels, labels) {
+				continue
+			}
+		}
+		members = append(members, server.UUID)
+	}
+	return members, nil
+}
+
+func hasTag(server upcloud.Server, tag string) bool {
+	for _, t := range server.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLabels(serverLabels upcloud.LabelSlice, labels map[string]interface{}) bool {
+	m := utils.LabelsSliceToMap(serverLabels)
+	for k, v := range labels {
+		if value, ok := m[k]; !ok || value != v.(string) {
+			return false
+		}
+	}
+	return true
+}
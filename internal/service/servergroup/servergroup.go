@@ -2,20 +2,25 @@ package servergroup
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const (
 	titleDescription   = "Title of your server group"
-	membersDescription = "UUIDs of the servers that are members of this group"
+	membersDescription = "UUIDs of the servers that are members of this group. Use `member_selector` instead to select the members by labels or tag."
 	// Lines > 1 should have one level of indentation to keep them under the right list item
 	antiAffinityPolicyDescription = `Defines if a server group is an anti-affinity group. Setting this to ` + "`strict` or `yes`" + ` will
 	result in all servers in the group being placed on separate compute hosts. The value can be ` + "`strict`, `yes`, or `no`" + `.
@@ -29,7 +34,15 @@ const (
 
 	Plese also note that anti-affinity policies are only applied on server start. This means that if anti-affinity
 	policies in server group are not met, you need to manually restart the servers in said group,
-	for example via API, UpCloud Control Panel or upctl (UpCloud CLI)`
+	for example via API, UpCloud Control Panel or upctl (UpCloud CLI), or set ` + "`enforce_policy` to `restart`" + `.`
+	enforcePolicyDescription = `Defines how the anti-affinity policy is enforced after the members or the policy of the group change. The value can be ` + "`none` or `restart`" + `.
+
+	* ` + "`none`" + ` does not touch the members of the group
+	* ` + "`restart`" + ` restarts the members that do not meet the anti-affinity policy one at a time, so that they are placed on separate hosts. Stopped members are not started. The members are stopped as defined by ` + "`stop_type` and `graceful_shutdown_timeout`" + ` of the group.`
+	policySatisfiedDescription = "Whether all started members of the group meet the anti-affinity policy"
+
+	enforcePolicyNone    = "none"
+	enforcePolicyRestart = "restart"
 )
 
 func ResourceServerGroup() *schema.Resource {
@@ -41,13 +54,18 @@ func ResourceServerGroup() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+		},
 		Schema: map[string]*schema.Schema{
 			"title": {
 				Description: titleDescription,
 				Type:        schema.TypeString,
 				Required:    true,
 			},
-			"labels": utils.LabelsSchema("server group"),
+			"labels":     utils.LabelsSchema("server group"),
+			"labels_all": utils.LabelsAllSchema("server group"),
 			"members": {
 				Description: membersDescription,
 				Type:        schema.TypeSet,
@@ -55,7 +73,9 @@ func ResourceServerGroup() *schema.Resource {
 					Type: schema.TypeString,
 				},
 				Optional: true,
+				Computed: true,
 			},
+			"member_selector": memberSelectorSchema(),
 			"anti_affinity_policy": {
 				Description: antiAffinityPolicyDescription,
 				Type:        schema.TypeString,
@@ -67,15 +87,51 @@ func ResourceServerGroup() *schema.Resource {
 					string(upcloud.ServerGroupAntiAffinityPolicyStrict),
 				}, false)),
 			},
+			"enforce_policy": {
+				Description: enforcePolicyDescription,
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     enforcePolicyNone,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					enforcePolicyNone,
+					enforcePolicyRestart,
+				}, false)),
+			},
+			"stop_type": {
+				Description: "The type of stop used when restarting members to enforce the anti-affinity policy. With `soft` stop, the server is asked to shut down gracefully and is forcibly stopped if it has not shut down within `graceful_shutdown_timeout`. With `hard` stop, the server is stopped immediately.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     utils.DefaultStopType,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+					upcloud.StopTypeSoft,
+					upcloud.StopTypeHard,
+				}, false)),
+			},
+			"graceful_shutdown_timeout": {
+				Description:      "The time (in seconds) to wait for members to shut down gracefully before forcibly stopping them, when `stop_type` is `soft`. Use the largest `graceful_shutdown_timeout` of the member servers to not stop them faster than the servers themselves would be stopped.",
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          utils.DefaultGracefulShutdownTimeout,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+			},
+			"policy_satisfied": {
+				Description: policySatisfiedDescription,
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
 		},
+		CustomizeDiff: customdiff.Sequence(
+			utils.MergeDefaultLabels,
+			planMembers,
+		),
 	}
 }
 
 func resourceServerGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	baseErrMsg := "creating server group failed"
 
-	req, err := createServerGroupRequestFromConfig(ctx, d)
+	req, err := createServerGroupRequestFromConfig(ctx, d, meta)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -99,12 +155,16 @@ func resourceServerGroupCreate(ctx context.Context, d *schema.ResourceData, meta
 
 	d.SetId(group.UUID)
 
+	if len(group.Members) > 0 {
+		diags = append(diags, enforceAntiAffinityPolicy(ctx, d, meta, d.Timeout(schema.TimeoutCreate))...)
+	}
+
 	diags = append(diags, resourceServerGroupRead(ctx, d, meta)...)
 	return diags
 }
 
 func resourceServerGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	baseErrMsg := "reading server group data failed"
 
 	group, err := svc.GetServerGroup(ctx, &request.GetServerGroupRequest{UUID: d.Id()})
@@ -112,7 +172,7 @@ func resourceServerGroupRead(ctx context.Context, d *schema.ResourceData, meta i
 		return utils.HandleResourceError(d.Get("name").(string), d, err)
 	}
 
-	err = setServerGroupData(group, d)
+	err = setServerGroupData(group, d, meta)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -125,10 +185,10 @@ func resourceServerGroupRead(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceServerGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 	baseErrMsg := "modifying server group data failed"
 
-	req, err := modifyServerGroupRequestFromConfig(ctx, d)
+	req, err := modifyServerGroupRequestFromConfig(ctx, d, meta)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -148,12 +208,16 @@ func resourceServerGroupUpdate(ctx context.Context, d *schema.ResourceData, meta
 		return diags
 	}
 
+	if d.HasChanges("members", "anti_affinity_policy", "enforce_policy") {
+		diags = append(diags, enforceAntiAffinityPolicy(ctx, d, meta, d.Timeout(schema.TimeoutUpdate))...)
+	}
+
 	diags = append(diags, resourceServerGroupRead(ctx, d, meta)...)
 	return diags
 }
 
 func resourceServerGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
-	svc := meta.(*service.Service)
+	svc := meta.(*config.Meta).Service
 
 	err := svc.DeleteServerGroup(ctx, &request.DeleteServerGroupRequest{UUID: d.Id()})
 	if err != nil {
@@ -163,7 +227,7 @@ func resourceServerGroupDelete(ctx context.Context, d *schema.ResourceData, meta
 	return diags
 }
 
-func setServerGroupData(group *upcloud.ServerGroup, d *schema.ResourceData) error {
+func setServerGroupData(group *upcloud.ServerGroup, d *schema.ResourceData, meta interface{}) error {
 	if err := d.Set("title", group.Title); err != nil {
 		return err
 	}
@@ -176,10 +240,103 @@ func setServerGroupData(group *upcloud.ServerGroup, d *schema.ResourceData) erro
 		return err
 	}
 
-	return d.Set("labels", utils.LabelSliceToMap(group.Labels))
+	if err := d.Set("policy_satisfied", len(unmetMembers(group)) == 0); err != nil {
+		return err
+	}
+
+	return utils.SetLabels(d, meta, utils.LabelSliceToMap(group.Labels))
+}
+
+// enforceAntiAffinityPolicy restarts the members of the group that do not meet the anti-affinity policy, if
+// enforce_policy is set to restart. The members are restarted one at a time and the status of the group is read again
+// after each restart, as moving one member may be enough to satisfy the policy for the others.
+func enforceAntiAffinityPolicy(ctx context.Context, d *schema.ResourceData, meta interface{}, timeout time.Duration) (diags diag.Diagnostics) {
+	if d.Get("enforce_policy").(string) != enforcePolicyRestart ||
+		d.Get("anti_affinity_policy").(string) == string(upcloud.ServerGroupAntiAffinityPolicyOff) {
+		return diags
+	}
+
+	svc := meta.(*config.Meta).Service
+	baseErrMsg := "enforcing server group anti-affinity policy failed"
+
+	restarted := make(map[string]bool)
+	for {
+		group, err := svc.GetServerGroup(ctx, &request.GetServerGroupRequest{UUID: d.Id()})
+		if err != nil {
+			return append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  baseErrMsg,
+				Detail:   err.Error(),
+			})
+		}
+
+		var next string
+		for _, uuid := range unmetMembers(group) {
+			if !restarted[uuid] {
+				next = uuid
+				break
+			}
+		}
+		if next == "" {
+			if unmet := unmetMembers(group); len(unmet) > 0 {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "server group anti-affinity policy is not satisfied",
+					Detail:   fmt.Sprintf("servers %s do not meet the anti-affinity policy of the group after restarting them", strings.Join(unmet, ", ")),
+				})
+			}
+			return diags
+		}
+
+		restarted[next] = true
+		stop := request.StopServerRequest{
+			UUID:     next,
+			StopType: d.Get("stop_type").(string),
+			Timeout:  time.Duration(d.Get("graceful_shutdown_timeout").(int)) * time.Second,
+		}
+		if err := restartServer(ctx, meta, stop, timeout); err != nil {
+			return append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  baseErrMsg,
+				Detail:   err.Error(),
+			})
+		}
+	}
+}
+
+// restartServer stops and starts the server, so that it is placed on a host again.
+func restartServer(ctx context.Context, meta interface{}, stop request.StopServerRequest, timeout time.Duration) error {
+	uuid := stop.UUID
+	unlock := utils.LockServers(ctx, meta, uuid)
+	defer unlock()
+
+	server, err := meta.(*config.Meta).Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+	if err != nil {
+		return err
+	}
+	if server.State == upcloud.ServerStateStopped {
+		return nil
+	}
+
+	tflog.Info(ctx, "restarting server to enforce anti-affinity policy", map[string]interface{}{"uuid": uuid})
+	if err := utils.VerifyServerStopped(ctx, stop, timeout, meta); err != nil {
+		return err
+	}
+	return utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: uuid}, timeout, meta)
+}
+
+// unmetMembers returns the UUIDs of the members that do not meet the anti-affinity policy of the group.
+func unmetMembers(group *upcloud.ServerGroup) []string {
+	var unmet []string
+	for _, status := range group.AntiAffinityStatus {
+		if status.Status == upcloud.ServerAntiAffinityStatusUnmet {
+			unmet = append(unmet, status.ServerUUID)
+		}
+	}
+	return unmet
 }
 
-func createServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceData) (*request.CreateServerGroupRequest, error) {
+func createServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceData, meta interface{}) (*request.CreateServerGroupRequest, error) {
 	result := &request.CreateServerGroupRequest{
 		Title: d.Get("title").(string),
 	}
@@ -197,8 +354,8 @@ func createServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceD
 		result.Members = membersSlice
 	}
 
-	labels, labelsWereSet := d.GetOk("labels")
-	if labelsWereSet {
+	labels := utils.LabelsWithDefaults(d, meta)
+	if len(labels) > 0 {
 		labelsSlice, err := utils.MapOfStringsToLabelSlice(ctx, labels)
 		if err != nil {
 			return result, err
@@ -210,7 +367,7 @@ func createServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceD
 	return result, nil
 }
 
-func modifyServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceData) (*request.ModifyServerGroupRequest, error) {
+func modifyServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceData, meta interface{}) (*request.ModifyServerGroupRequest, error) {
 	result := &request.ModifyServerGroupRequest{
 		Title: d.Get("title").(string),
 		UUID:  d.Id(),
@@ -229,8 +386,8 @@ func modifyServerGroupRequestFromConfig(ctx context.Context, d *schema.ResourceD
 		result.Members = &membersUUIDSlice
 	}
 
-	if d.HasChange("labels") {
-		labels, err := utils.MapOfStringsToLabelSlice(ctx, d.Get("labels"))
+	if d.HasChanges("labels", "labels_all") {
+		labels, err := utils.MapOfStringsToLabelSlice(ctx, utils.LabelsWithDefaults(d, meta))
 		if err != nil {
 			return result, err
 		}
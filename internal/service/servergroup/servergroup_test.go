@@ -0,0 +1,140 @@
+package servergroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
+)
+
+func onHost(host int) func(*request.CreateServerRequest) {
+	return func(r *request.CreateServerRequest) {
+		r.Host = host
+	}
+}
+
+func TestResourceServerGroup_enforcePolicy(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	const host = 1234567890
+	first := fakeapi.CreateServer(t, meta.Service, "first.example.com", onHost(host))
+	second := fakeapi.CreateServer(t, meta.Service, "second.example.com", onHost(host))
+	stopped := fakeapi.CreateServer(t, meta.Service, "stopped.example.com", onHost(host))
+	require.NoError(t, utils.VerifyServerStopped(ctx, request.StopServerRequest{UUID: stopped.UUID}, time.Minute, meta))
+
+	getServer := func(uuid string) *upcloud.ServerDetails {
+		t.Helper()
+		details, err := meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+		require.NoError(t, err)
+		return details
+	}
+
+	r := ResourceServerGroup()
+	create := func(enforcePolicy string, members ...interface{}) *schema.ResourceData {
+		t.Helper()
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"title":                "group",
+			"anti_affinity_policy": string(upcloud.ServerGroupAntiAffinityPolicyStrict),
+			"enforce_policy":       enforcePolicy,
+			"stop_type":            upcloud.StopTypeHard,
+			"members":              members,
+		})
+		diags := r.CreateContext(ctx, d, meta)
+		require.False(t, diags.HasError(), diags)
+		return d
+	}
+
+	// Policy is not enforced by default
+	d := create(enforcePolicyNone, first.UUID, second.UUID)
+	assert.False(t, d.Get("policy_satisfied").(bool))
+	assert.Equal(t, host, getServer(first.UUID).Host)
+	assert.Equal(t, host, getServer(second.UUID).Host)
+	require.False(t, r.DeleteContext(ctx, d, meta).HasError())
+
+	d = create(enforcePolicyRestart, first.UUID, second.UUID, stopped.UUID)
+	assert.True(t, d.Get("policy_satisfied").(bool))
+	firstDetails, secondDetails := getServer(first.UUID), getServer(second.UUID)
+	assert.NotEqual(t, firstDetails.Host, secondDetails.Host)
+	assert.True(t, firstDetails.Host == host || secondDetails.Host == host, "only one of the members should be moved")
+	assert.Equal(t, upcloud.ServerStateStarted, firstDetails.State)
+	assert.Equal(t, upcloud.ServerStateStarted, secondDetails.State)
+
+	// Stopped members are not started
+	details := getServer(stopped.UUID)
+	assert.Equal(t, host, details.Host)
+	assert.Equal(t, upcloud.ServerStateStopped, details.State)
+}
+
+func TestUnmetMembers(t *testing.T) {
+	assert.Empty(t, unmetMembers(&upcloud.ServerGroup{}))
+	assert.Equal(t, []string{"b"}, unmetMembers(&upcloud.ServerGroup{
+		AntiAffinityStatus: []upcloud.ServerGroupMemberAntiAffinityStatus{
+			{ServerUUID: "a", Status: upcloud.ServerAntiAffinityStatusMet},
+			{ServerUUID: "b", Status: upcloud.ServerAntiAffinityStatusUnmet},
+		},
+	}))
+}
+
+func TestResourceServerGroup_memberSelector(t *testing.T) {
+	meta := fakeapi.NewMeta(t)
+	ctx := context.Background()
+
+	web := upcloud.Label{Key: "role", Value: "web"}
+	first := fakeapi.CreateServer(t, meta.Service, "first.example.com", fakeapi.WithLabels(web))
+	second := fakeapi.CreateServer(t, meta.Service, "second.example.com", fakeapi.WithLabels(web, upcloud.Label{Key: "env", Value: "prod"}))
+	fakeapi.CreateServer(t, meta.Service, "db.example.com", fakeapi.WithLabels(upcloud.Label{Key: "role", Value: "db"}))
+
+	r := ResourceServerGroup()
+	plan := func(state *terraform.InstanceState, raw map[string]interface{}) *schema.ResourceData {
+		t.Helper()
+		state.RawConfig = cty.ObjectVal(map[string]cty.Value{"title": cty.StringVal("group")})
+		diff, err := r.SimpleDiff(ctx, state, terraform.NewResourceConfigRaw(raw), meta)
+		require.NoError(t, err)
+		d, err := schema.InternalMap(r.Schema).Data(state, diff)
+		require.NoError(t, err)
+		return d
+	}
+	selector := func(selector map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"title":           "group",
+			"member_selector": []interface{}{selector},
+		}
+	}
+	members := func(d *schema.ResourceData) []string {
+		group, err := meta.Service.GetServerGroup(ctx, &request.GetServerGroupRequest{UUID: d.Id()})
+		require.NoError(t, err)
+		return group.Members
+	}
+
+	d := plan(&terraform.InstanceState{}, selector(map[string]interface{}{"labels": map[string]interface{}{"role": "web"}}))
+	require.False(t, r.CreateContext(ctx, d, meta).HasError())
+	assert.ElementsMatch(t, []string{first.UUID, second.UUID}, members(d))
+
+	// Servers that no longer match the selector are removed from the group
+	d = plan(d.State(), selector(map[string]interface{}{"labels": map[string]interface{}{"role": "web", "env": "prod"}}))
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+	assert.Equal(t, []string{second.UUID}, members(d))
+
+	_, err := meta.Service.CreateTag(ctx, &request.CreateTagRequest{Tag: upcloud.Tag{Name: "web", Servers: upcloud.TagServerSlice{first.UUID}}})
+	require.NoError(t, err)
+	d = plan(d.State(), selector(map[string]interface{}{"tag": "WEB"}))
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+	assert.Equal(t, []string{first.UUID}, members(d))
+
+	// Members are removed, if neither members nor member_selector is configured
+	d = plan(d.State(), map[string]interface{}{"title": "group"})
+	require.False(t, r.UpdateContext(ctx, d, meta).HasError())
+	assert.Empty(t, members(d))
+	assert.Empty(t, d.Get("members").(*schema.Set).List())
+}
@@ -11,16 +11,17 @@ import (
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 )
 
 func ResourceStorage() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description:   "Manages UpCloud storage block devices.",
 		CreateContext: resourceStorageCreate,
 		ReadContext:   resourceStorageRead,
@@ -29,7 +30,12 @@ func ResourceStorage() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: map[string]*schema.Schema{
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute * 20),
+			Update: schema.DefaultTimeout(time.Minute * 20),
+			Delete: schema.DefaultTimeout(time.Minute * 20),
+		},
+		Schema: utils.JoinSchemas(map[string]*schema.Schema{
 			"size": {
 				Description:  "The size of the storage in gigabytes",
 				Type:         schema.TypeInt,
@@ -51,9 +57,10 @@ func ResourceStorage() *schema.Resource {
 				ValidateFunc: validation.StringLenBetween(0, 64),
 			},
 			"zone": {
-				Description: "The zone in which the storage will be created, e.g. `de-fra1`. You can list available zones with `upctl zone list`.",
+				Description: "The zone in which the storage will be created, e.g. `de-fra1`. You can list available zones with `upctl zone list`. Defaults to the `zone` of the provider.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 			},
 			"clone": {
@@ -145,12 +152,18 @@ func ResourceStorage() *schema.Resource {
 				Optional:    true,
 				Default:     false,
 			},
-		},
+			"deletion_protection": utils.DeletionProtectionSchema("storage"),
+		}, utils.ServerStopSchema("to resize the storage or to detach the storage from an IDE controller")),
 	}
+	r.CustomizeDiff = customdiff.Sequence(
+		utils.SetDefaultZone,
+		utils.PreventProtectedReplacement(r.Schema),
+	)
+	return r
 }
 
 func resourceStorageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -196,7 +209,7 @@ func resourceStorageCreate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceStorageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -289,13 +302,13 @@ func resourceStorageRead(ctx context.Context, d *schema.ResourceData, meta inter
 }
 
 func resourceStorageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 	diags := diag.Diagnostics{}
 
 	_, err := client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         d.Id(),
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      d.Timeout(schema.TimeoutUpdate),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -324,13 +337,29 @@ func resourceStorageUpdate(ctx context.Context, d *schema.ResourceData, meta int
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	defer utils.LockServers(ctx, meta, storageDetails.ServerUUIDs...)()
+
 	// need to shut down server if resizing
 	if len(storageDetails.ServerUUIDs) > 0 && d.HasChange("size") {
-		err := utils.VerifyServerStopped(ctx, request.StopServerRequest{UUID: storageDetails.ServerUUIDs[0]}, meta)
+		serverUUID := storageDetails.ServerUUIDs[0]
+		serverDetails, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{
+			UUID: serverUUID,
+		})
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		if _, err := utils.WithRetry(func() (interface{}, error) { return client.ModifyStorage(ctx, &req) }, 20, time.Second*5); err != nil {
+		if err := utils.CheckServerStopAllowed(d, serverDetails); err != nil {
+			return diag.FromErr(err)
+		}
+
+		err = utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, serverUUID), d.Timeout(schema.TimeoutUpdate), meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err := utils.RetryWhileStorageBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := client.ModifyStorage(ctx, &req)
+			return err
+		}); err != nil {
 			return diag.FromErr(err)
 		}
 
@@ -345,11 +374,14 @@ func resourceStorageUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		}
 
 		// No need to pass host explicitly here, as the server will be started on old host by default (for private clouds)
-		if err = utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: storageDetails.ServerUUIDs[0]}, meta); err != nil {
+		if err = utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: serverUUID}, d.Timeout(schema.TimeoutUpdate), meta); err != nil {
 			return diag.FromErr(err)
 		}
 	} else {
-		if _, err := client.ModifyStorage(ctx, &req); err != nil {
+		if err := utils.RetryWhileStorageBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutUpdate), func() error {
+			_, err := client.ModifyStorage(ctx, &req)
+			return err
+		}); err != nil {
 			return diag.FromErr(err)
 		}
 
@@ -370,7 +402,11 @@ func resourceStorageUpdate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceStorageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	if diags := utils.CheckDeletionProtection(d); diags.HasError() {
+		return diags
+	}
+
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -379,7 +415,7 @@ func resourceStorageDelete(ctx context.Context, d *schema.ResourceData, meta int
 	_, err := client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         d.Id(),
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      d.Timeout(schema.TimeoutDelete),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -395,6 +431,8 @@ func resourceStorageDelete(ctx context.Context, d *schema.ResourceData, meta int
 
 	if len(storageDetails.ServerUUIDs) > 0 {
 		serverUUID := storageDetails.ServerUUIDs[0]
+		defer utils.LockServers(ctx, meta, serverUUID)()
+
 		// Get server details for retrieving the address that is to be used when detaching the storage
 		serverDetails, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{
 			UUID: serverUUID,
@@ -406,22 +444,26 @@ func resourceStorageDelete(ctx context.Context, d *schema.ResourceData, meta int
 		if storageDevice := serverDetails.StorageDevice(d.Id()); storageDevice != nil {
 			// ide devices can only be detached from stopped servers
 			if strings.HasPrefix(storageDevice.Address, "ide") {
-				err = utils.VerifyServerStopped(ctx, request.StopServerRequest{UUID: serverUUID}, meta)
+				if err := utils.CheckServerStopAllowed(d, serverDetails); err != nil {
+					return diag.FromErr(err)
+				}
+				err = utils.VerifyServerStopped(ctx, utils.BuildStopServerRequest(d, serverUUID), d.Timeout(schema.TimeoutDelete), meta)
 				if err != nil {
 					return diag.FromErr(err)
 				}
 			}
 
-			_, err = utils.WithRetry(func() (interface{}, error) {
-				return client.DetachStorage(ctx, &request.DetachStorageRequest{ServerUUID: serverUUID, Address: storageDevice.Address})
-			}, 20, time.Second*3)
+			err = utils.RetryWhileServerBusy(ctx, meta, serverUUID, d.Timeout(schema.TimeoutDelete), func() error {
+				_, err := client.DetachStorage(ctx, &request.DetachStorageRequest{ServerUUID: serverUUID, Address: storageDevice.Address})
+				return err
+			})
 			if err != nil {
 				return diag.FromErr(err)
 			}
 
 			if strings.HasPrefix(storageDevice.Address, "ide") && serverDetails.State != upcloud.ServerStateStopped {
 				// No need to pass host explicitly here, as the server will be started on old host by default (for private clouds)
-				if err = utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: serverUUID}, meta); err != nil {
+				if err = utils.VerifyServerStarted(ctx, request.StartServerRequest{UUID: serverUUID}, d.Timeout(schema.TimeoutDelete), meta); err != nil {
 					return diag.FromErr(err)
 				}
 			}
@@ -431,7 +473,9 @@ func resourceStorageDelete(ctx context.Context, d *schema.ResourceData, meta int
 	deleteStorageRequest := &request.DeleteStorageRequest{
 		UUID: d.Id(),
 	}
-	err = client.DeleteStorage(ctx, deleteStorageRequest)
+	err = utils.RetryWhileStorageBusy(ctx, meta, d.Id(), d.Timeout(schema.TimeoutDelete), func() error {
+		return client.DeleteStorage(ctx, deleteStorageRequest)
+	})
 
 	if err != nil {
 		return diag.FromErr(err)
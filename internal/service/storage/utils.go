@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
@@ -78,7 +79,7 @@ func cloneStorage(
 	originalStorageDevice, err := client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         cloneStorageRequest.UUID,
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -88,7 +89,11 @@ func cloneStorage(
 		return diag.Errorf("cloned storage device should be at least the same size as the original one")
 	}
 
-	storage, err := client.CloneStorage(ctx, &cloneStorageRequest)
+	var storage *upcloud.StorageDetails
+	err = utils.RetryWhileBusy(ctx, d.Timeout(schema.TimeoutCreate), func() (err error) {
+		storage, err = client.CloneStorage(ctx, &cloneStorageRequest)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -96,7 +101,7 @@ func cloneStorage(
 	storage, err = client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         storage.UUID,
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -115,7 +120,7 @@ func cloneStorage(
 		_, err = client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 			UUID:         storage.UUID,
 			DesiredState: upcloud.StorageStateOnline,
-			Timeout:      15 * time.Minute,
+			Timeout:      d.Timeout(schema.TimeoutCreate),
 		})
 		if err != nil {
 			return diag.FromErr(err)
@@ -171,7 +176,7 @@ func createStorage(
 	_, err = client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         storage.UUID,
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      d.Timeout(schema.TimeoutCreate),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -181,15 +186,15 @@ func createStorage(
 		importReq.StorageUUID = storage.UUID
 		_, err := client.CreateStorageImport(ctx, importReq)
 		if err != nil {
-			return diagAndTidy(ctx, client, storage.UUID, err)
+			return diagAndTidy(ctx, client, storage.UUID, d.Timeout(schema.TimeoutCreate), err)
 		}
 
 		_, err = client.WaitForStorageImportCompletion(ctx, &request.WaitForStorageImportCompletionRequest{
 			StorageUUID: storage.UUID,
-			Timeout:     15 * time.Minute,
+			Timeout:     d.Timeout(schema.TimeoutCreate),
 		})
 		if err != nil {
-			return diagAndTidy(ctx, client, storage.UUID, err)
+			return diagAndTidy(ctx, client, storage.UUID, d.Timeout(schema.TimeoutCreate), err)
 		}
 
 		// Imported storage will enter a 'syncing' state for a while. Storage in this
@@ -197,10 +202,10 @@ func createStorage(
 		_, err = client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 			UUID:         storage.UUID,
 			DesiredState: upcloud.StorageStateSyncing,
-			Timeout:      15 * time.Minute,
+			Timeout:      d.Timeout(schema.TimeoutCreate),
 		})
 		if err != nil {
-			return diagAndTidy(ctx, client, storage.UUID, err)
+			return diagAndTidy(ctx, client, storage.UUID, d.Timeout(schema.TimeoutCreate), err)
 		}
 	}
 
@@ -226,11 +231,11 @@ func isStorageSimpleBackupEnabled(ctx context.Context, service *service.Service,
 	return false, nil
 }
 
-func diagAndTidy(ctx context.Context, client *service.Service, storageUUID string, err error) diag.Diagnostics {
+func diagAndTidy(ctx context.Context, client *service.Service, storageUUID string, timeout time.Duration, err error) diag.Diagnostics {
 	_, waitErr := client.WaitForStorageState(ctx, &request.WaitForStorageStateRequest{
 		UUID:         storageUUID,
 		DesiredState: upcloud.StorageStateOnline,
-		Timeout:      15 * time.Minute,
+		Timeout:      timeout,
 	})
 	if waitErr != nil {
 		return diag.Errorf("wait for storage after import error: %s", waitErr.Error())
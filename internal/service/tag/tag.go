@@ -4,9 +4,10 @@ import (
 	"context"
 	"regexp"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -50,7 +51,8 @@ func ResourceTag() *schema.Resource {
 }
 
 func resourceTagCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, utils.ExpandStrings(d.Get("servers"))...)()
 
 	createTagRequest := &request.CreateTagRequest{
 		Tag: upcloud.Tag{
@@ -70,7 +72,11 @@ func resourceTagCreate(ctx context.Context, d *schema.ResourceData, meta interfa
 		createTagRequest.Servers = serversList
 	}
 
-	tag, err := client.CreateTag(ctx, createTagRequest)
+	var tag *upcloud.Tag
+	err := utils.RetryWhileBusy(ctx, d.Timeout(schema.TimeoutCreate), func() (err error) {
+		tag, err = client.CreateTag(ctx, createTagRequest)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -81,7 +87,7 @@ func resourceTagCreate(ctx context.Context, d *schema.ResourceData, meta interfa
 }
 
 func resourceTagRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
 
 	var diags diag.Diagnostics
 
@@ -125,7 +131,9 @@ func resourceTagRead(ctx context.Context, d *schema.ResourceData, meta interface
 }
 
 func resourceTagUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	oldServers, newServers := d.GetChange("servers")
+	defer utils.LockServers(ctx, meta, append(utils.ExpandStrings(oldServers), utils.ExpandStrings(newServers)...)...)()
 
 	r := &request.ModifyTagRequest{
 		Name: d.Id(),
@@ -147,7 +155,10 @@ func resourceTagUpdate(ctx context.Context, d *schema.ResourceData, meta interfa
 		r.Tag.Servers = serversList
 	}
 
-	_, err := client.ModifyTag(ctx, r)
+	err := utils.RetryWhileBusy(ctx, d.Timeout(schema.TimeoutUpdate), func() error {
+		_, err := client.ModifyTag(ctx, r)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -156,14 +167,17 @@ func resourceTagUpdate(ctx context.Context, d *schema.ResourceData, meta interfa
 }
 
 func resourceTagDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*service.Service)
+	client := meta.(*config.Meta).Service
+	defer utils.LockServers(ctx, meta, utils.ExpandStrings(d.Get("servers"))...)()
 
 	var diags diag.Diagnostics
 
 	deleteTagRequest := &request.DeleteTagRequest{
 		Name: d.Id(),
 	}
-	err := client.DeleteTag(ctx, deleteTagRequest)
+	err := utils.RetryWhileBusy(ctx, d.Timeout(schema.TimeoutDelete), func() error {
+		return client.DeleteTag(ctx, deleteTagRequest)
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
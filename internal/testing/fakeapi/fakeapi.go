@@ -0,0 +1,304 @@
+// Package fakeapi implements an in-memory UpCloud API that can be used to test resource CRUD operations without
+// network access or an UpCloud account.
+package fakeapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/client"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+)
+
+const (
+	// Username and Password are the credentials accepted by the fake API.
+	Username = "fakeapi"
+	Password = "fakeapi"
+	// Token is the API token accepted by the fake API.
+	Token = "ucat_fakeapi"
+
+	// Ubuntu2204 and Ubuntu2004 are the UUIDs of the Ubuntu Server templates of the fake API.
+	Ubuntu2204 = "01000000-0000-4000-8000-000030220200"
+	Ubuntu2004 = "01000000-0000-4000-8000-000030200200"
+
+	apiPrefix = "/" + client.APIVersion
+)
+
+// Server is an in-process fake of the UpCloud API. It keeps all state in memory and simulates the state transitions
+// of the real API, e.g. a new server moves from `maintenance` to `started` and a cloned storage from `maintenance`
+// to `online`.
+type Server struct {
+	*httptest.Server
+
+	// TransitionDelay is the time it takes for a resource to reach its target state. With zero delay, the target state
+	// is visible on the first read after the operation that triggered the transition.
+	TransitionDelay time.Duration
+
+	mu                 sync.Mutex
+	routes             []route
+	servers            map[string]*serverEntry
+	storages           map[string]*storageEntry
+	networks           map[string]*upcloud.Network
+	routers            map[string]*upcloud.Router
+	tags               map[string]*upcloud.Tag
+	serverGroups       map[string]*upcloud.ServerGroup
+	ipAddresses        map[string]*upcloud.IPAddress
+	loadBalancers      map[string]*loadBalancerEntry
+	certificateBundles map[string]*upcloud.LoadBalancerCertificateBundle
+	zones              []upcloud.Zone
+	plans              []upcloud.Plan
+
+	// nextAddress and nextMAC are counters for generating unique IP and MAC addresses
+	nextAddress int
+	nextMAC     int
+}
+
+// New starts a new fake API server. The server should be closed with Close when it is no longer needed.
+func New() *Server {
+	s := newServer()
+	s.Start()
+	return s
+}
+
+// NewTLS starts a new fake API server that uses TLS. The certificate of the server is available in Certificate.
+func NewTLS() *Server {
+	s := newServer()
+	s.StartTLS()
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{
+		servers:            make(map[string]*serverEntry),
+		storages:           make(map[string]*storageEntry),
+		networks:           make(map[string]*upcloud.Network),
+		routers:            make(map[string]*upcloud.Router),
+		tags:               make(map[string]*upcloud.Tag),
+		serverGroups:       make(map[string]*upcloud.ServerGroup),
+		ipAddresses:        make(map[string]*upcloud.IPAddress),
+		loadBalancers:      make(map[string]*loadBalancerEntry),
+		certificateBundles: make(map[string]*upcloud.LoadBalancerCertificateBundle),
+	}
+	s.seed()
+	s.registerRoutes()
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Service returns an UpCloud API service that is connected to the fake API.
+func (s *Server) Service() *service.Service {
+	return service.New(client.New(Username, Password, client.WithBaseURL(s.URL), client.WithHTTPClient(s.Client())))
+}
+
+func (s *Server) registerRoutes() {
+	s.handle(http.MethodGet, "/account", s.getAccount)
+	s.handle(http.MethodGet, "/zone", s.getZones)
+	s.handle(http.MethodGet, "/plan", s.getPlans)
+	s.registerServerRoutes()
+	s.registerStorageRoutes()
+	s.registerNetworkRoutes()
+	s.registerTagRoutes()
+	s.registerServerGroupRoutes()
+	s.registerFirewallRoutes()
+	s.registerIPAddressRoutes()
+	s.registerLoadBalancerRoutes()
+}
+
+func (s *Server) seed() {
+	s.zones = []upcloud.Zone{
+		{ID: "de-fra1", Description: "Frankfurt #1", Public: upcloud.True},
+		{ID: "fi-hel1", Description: "Helsinki #1", Public: upcloud.True},
+		{ID: "fi-hel2", Description: "Helsinki #2", Public: upcloud.True},
+		{ID: "nl-ams1", Description: "Amsterdam #1", Public: upcloud.True},
+		{ID: "pl-waw1", Description: "Warsaw #1", Public: upcloud.True},
+		{ID: "uk-lon1", Description: "London #1", Public: upcloud.True},
+		{ID: "us-nyc1", Description: "New York #1", Public: upcloud.True},
+	}
+	s.plans = []upcloud.Plan{
+		{Name: "1xCPU-1GB", CoreNumber: 1, MemoryAmount: 1024, StorageSize: 25, StorageTier: upcloud.StorageTierMaxIOPS, PublicTrafficOut: 1024},
+		{Name: "1xCPU-2GB", CoreNumber: 1, MemoryAmount: 2048, StorageSize: 50, StorageTier: upcloud.StorageTierMaxIOPS, PublicTrafficOut: 2048},
+		{Name: "2xCPU-4GB", CoreNumber: 2, MemoryAmount: 4096, StorageSize: 80, StorageTier: upcloud.StorageTierMaxIOPS, PublicTrafficOut: 4096},
+		{Name: "4xCPU-8GB", CoreNumber: 4, MemoryAmount: 8192, StorageSize: 160, StorageTier: upcloud.StorageTierMaxIOPS, PublicTrafficOut: 5120},
+	}
+	for _, t := range []struct{ uuid, title string }{
+		{Ubuntu2004, "Ubuntu Server 20.04 LTS (Focal Fossa)"},
+		{Ubuntu2204, "Ubuntu Server 22.04 LTS (Jammy Jellyfish)"},
+		{"01000000-0000-4000-8000-000020070100", "Debian GNU/Linux 12 (Bookworm)"},
+	} {
+		s.storages[t.uuid] = &storageEntry{
+			details: upcloud.StorageDetails{Storage: upcloud.Storage{
+				Access: upcloud.StorageAccessPublic,
+				Size:   4,
+				State:  upcloud.StorageStateOnline,
+				Tier:   upcloud.StorageTierMaxIOPS,
+				Title:  t.title,
+				Type:   upcloud.StorageTypeTemplate,
+				UUID:   t.uuid,
+			}},
+		}
+	}
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, _ *http.Request, _ params) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"account": encode(upcloud.Account{Credits: 10000, UserName: Username})})
+}
+
+func (s *Server) getZones(w http.ResponseWriter, _ *http.Request, _ params) {
+	writeJSON(w, http.StatusOK, wrapList("zones", "zone", s.zones))
+}
+
+func (s *Server) getPlans(w http.ResponseWriter, _ *http.Request, _ params) {
+	writeJSON(w, http.StatusOK, wrapList("plans", "plan", s.plans))
+}
+
+type params map[string]string
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, p params)
+
+type route struct {
+	method  string
+	pattern []string
+	handler handlerFunc
+}
+
+// handle registers handler for the method and path pattern. Path segments wrapped in braces, e.g. `{uuid}`, match
+// any value and are passed to the handler as parameters.
+func (s *Server) handle(method, pattern string, handler handlerFunc) {
+	s.routes = append(s.routes, route{
+		method:  method,
+		pattern: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler: handler,
+	})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r) {
+		writeError(w, http.StatusUnauthorized, "AUTHENTICATION_FAILED", "Authentication failed using the given username and password.")
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Unknown API version.")
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rt := range s.routes {
+		if rt.method != r.Method {
+			continue
+		}
+		if p, ok := matchRoute(rt.pattern, segments); ok {
+			rt.handler(w, r, p)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("%s %s is not implemented by the fake API.", r.Method, r.URL.Path))
+}
+
+func authorized(r *http.Request) bool {
+	if r.Header.Get("Authorization") == "Bearer "+Token {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	return ok && username == Username && password == Password
+}
+
+func matchRoute(pattern, segments []string) (params, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	p := make(params)
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			p[strings.Trim(part, "{}")] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// transition is a pending state change of a resource.
+type transition struct {
+	to string
+	at time.Time
+}
+
+func (s *Server) newTransition(to string) *transition {
+	return &transition{to: to, at: time.Now().Add(s.TransitionDelay)}
+}
+
+// advance returns the state the resource should be in and whether the pending transition completed.
+func (t *transition) advance(current string) (string, bool) {
+	if t == nil || time.Now().Before(t.at) {
+		return current, false
+	}
+	return t.to, true
+}
+
+func newUUID(prefix byte) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[0] = prefix
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError writes an error in the legacy error format used by most of the UpCloud API endpoints.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"error_code":    code,
+			"error_message": message,
+		},
+	})
+}
+
+// writeProblem writes an error in the RFC7807 format used by the newer UpCloud API endpoints.
+func writeProblem(w http.ResponseWriter, status int, code, title string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(upcloud.Problem{
+		Type:   fmt.Sprintf("https://developers.upcloud.com/1.3/errors#ERROR_%s", code),
+		Title:  title,
+		Status: status,
+	})
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+}
@@ -0,0 +1,320 @@
+package fakeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/client"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestServer(t *testing.T, svc *service.Service) *upcloud.ServerDetails {
+	t.Helper()
+
+	return CreateServer(t, svc, "fakeapi.example.com", func(r *request.CreateServerRequest) {
+		r.Networking.Interfaces = append(r.Networking.Interfaces, request.CreateServerInterface{
+			Type:        upcloud.NetworkTypeUtility,
+			IPAddresses: request.CreateServerIPAddressSlice{{Family: upcloud.IPAddressFamilyIPv4}},
+		})
+	})
+}
+
+func TestServerLifecycle(t *testing.T) {
+	api := New()
+	defer api.Close()
+	api.TransitionDelay = 100 * time.Millisecond
+	svc := api.Service()
+	ctx := context.Background()
+
+	server := createTestServer(t, svc)
+	assert.Equal(t, upcloud.ServerStateMaintenance, server.State)
+	require.Len(t, server.StorageDevices, 1)
+	assert.Equal(t, 25, server.StorageDevices[0].Size)
+	require.Len(t, server.Networking.Interfaces, 2)
+	assert.Equal(t, upcloud.True, server.Networking.Interfaces[0].IPAddresses[0].PartOfPlan)
+
+	time.Sleep(api.TransitionDelay)
+	server, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, server.State)
+
+	_, err = svc.ModifyServer(ctx, &request.ModifyServerRequest{UUID: server.UUID, Plan: "2xCPU-4GB"})
+	var problem *upcloud.Problem
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, upcloud.ErrCodeServerStateIllegal, problem.ErrorCode())
+
+	_, err = svc.StopServer(ctx, &request.StopServerRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	time.Sleep(api.TransitionDelay)
+
+	server, err = svc.ModifyServer(ctx, &request.ModifyServerRequest{UUID: server.UUID, Plan: "2xCPU-4GB", Title: "modified"})
+	require.NoError(t, err)
+	assert.Equal(t, "2xCPU-4GB", server.Plan)
+	assert.Equal(t, 2, server.CoreNumber)
+	assert.Equal(t, "modified", server.Title)
+
+	ips, err := svc.GetIPAddresses(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ips.IPAddresses, 2)
+
+	require.NoError(t, svc.DeleteServerAndStorages(ctx, &request.DeleteServerAndStoragesRequest{UUID: server.UUID}))
+	_, err = svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+
+	storages, err := svc.GetStorages(ctx, &request.GetStoragesRequest{Access: upcloud.StorageAccessPrivate})
+	require.NoError(t, err)
+	assert.Empty(t, storages.Storages)
+	ips, err = svc.GetIPAddresses(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ips.IPAddresses)
+}
+
+func TestStorageClone(t *testing.T) {
+	api := New()
+	defer api.Close()
+	api.TransitionDelay = 100 * time.Millisecond
+	svc := api.Service()
+	ctx := context.Background()
+
+	storage, err := svc.CreateStorage(ctx, &request.CreateStorageRequest{Size: 10, Tier: upcloud.StorageTierMaxIOPS, Title: "source", Zone: "de-fra1"})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.StorageStateMaintenance, storage.State)
+	time.Sleep(api.TransitionDelay)
+
+	clone, err := svc.CloneStorage(ctx, &request.CloneStorageRequest{UUID: storage.UUID, Title: "clone", Zone: "de-fra1"})
+	require.NoError(t, err)
+	source, err := svc.GetStorageDetails(ctx, &request.GetStorageDetailsRequest{UUID: storage.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.StorageStateCloning, source.State)
+
+	time.Sleep(api.TransitionDelay)
+	for _, uuid := range []string{storage.UUID, clone.UUID} {
+		details, err := svc.GetStorageDetails(ctx, &request.GetStorageDetailsRequest{UUID: uuid})
+		require.NoError(t, err)
+		assert.Equal(t, upcloud.StorageStateOnline, details.State)
+		assert.Equal(t, 10, details.Size)
+	}
+}
+
+func TestNetworkAndRouter(t *testing.T) {
+	api := New()
+	defer api.Close()
+	svc := api.Service()
+	ctx := context.Background()
+
+	router, err := svc.CreateRouter(ctx, &request.CreateRouterRequest{Name: "router"})
+	require.NoError(t, err)
+
+	network, err := svc.CreateNetwork(ctx, &request.CreateNetworkRequest{
+		Name:   "network",
+		Zone:   "fi-hel1",
+		Router: router.UUID,
+		IPNetworks: upcloud.IPNetworkSlice{
+			{Address: "10.0.10.0/24", DHCP: upcloud.True, Family: upcloud.IPAddressFamilyIPv4, Gateway: "10.0.10.1"},
+		},
+		Labels: []upcloud.Label{{Key: "env", Value: "test"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, router.UUID, network.Router)
+	assert.Equal(t, []upcloud.Label{{Key: "env", Value: "test"}}, network.Labels)
+
+	router, err = svc.GetRouterDetails(ctx, &request.GetRouterDetailsRequest{UUID: router.UUID})
+	require.NoError(t, err)
+	require.Len(t, router.AttachedNetworks, 1)
+
+	var problem *upcloud.Problem
+	err = svc.DeleteRouter(ctx, &request.DeleteRouterRequest{UUID: router.UUID})
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, http.StatusConflict, problem.Status)
+
+	server := createTestServer(t, svc)
+	_, err = svc.StopServer(ctx, &request.StopServerRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	iface, err := svc.CreateNetworkInterface(ctx, &request.CreateNetworkInterfaceRequest{
+		ServerUUID:  server.UUID,
+		Type:        upcloud.NetworkTypePrivate,
+		NetworkUUID: network.UUID,
+		IPAddresses: request.CreateNetworkInterfaceIPAddressSlice{{Family: upcloud.IPAddressFamilyIPv4}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, iface.Index)
+	assert.Equal(t, "10.0.10.2", iface.IPAddresses[0].Address)
+
+	network, err = svc.GetNetworkDetails(ctx, &request.GetNetworkDetailsRequest{UUID: network.UUID})
+	require.NoError(t, err)
+	require.Len(t, network.Servers, 1)
+	assert.Equal(t, server.UUID, network.Servers[0].ServerUUID)
+
+	require.NoError(t, svc.DeleteNetworkInterface(ctx, &request.DeleteNetworkInterfaceRequest{ServerUUID: server.UUID, Index: iface.Index}))
+	require.NoError(t, svc.DetachNetworkRouter(ctx, &request.DetachNetworkRouterRequest{NetworkUUID: network.UUID}))
+	require.NoError(t, svc.DeleteRouter(ctx, &request.DeleteRouterRequest{UUID: router.UUID}))
+	require.NoError(t, svc.DeleteNetwork(ctx, &request.DeleteNetworkRequest{UUID: network.UUID}))
+}
+
+func TestTagsFirewallAndFloatingIP(t *testing.T) {
+	api := New()
+	defer api.Close()
+	svc := api.Service()
+	ctx := context.Background()
+
+	server := createTestServer(t, svc)
+
+	tag, err := svc.CreateTag(ctx, &request.CreateTagRequest{Tag: upcloud.Tag{Name: "web", Servers: upcloud.TagServerSlice{server.UUID}}})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.TagServerSlice{server.UUID}, tag.Servers)
+	details, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerTagSlice{"web"}, details.Tags)
+
+	require.NoError(t, svc.CreateFirewallRules(ctx, &request.CreateFirewallRulesRequest{
+		ServerUUID: server.UUID,
+		FirewallRules: request.FirewallRuleSlice{
+			{Action: upcloud.FirewallRuleActionAccept, Direction: upcloud.FirewallRuleDirectionIn, Family: upcloud.IPAddressFamilyIPv4, Protocol: upcloud.FirewallRuleProtocolTCP, DestinationPortStart: "22", DestinationPortEnd: "22"},
+			{Action: upcloud.FirewallRuleActionDrop, Direction: upcloud.FirewallRuleDirectionIn},
+		},
+	}))
+	rules, err := svc.GetFirewallRules(ctx, &request.GetFirewallRulesRequest{ServerUUID: server.UUID})
+	require.NoError(t, err)
+	require.Len(t, rules.FirewallRules, 2)
+	assert.Equal(t, 2, rules.FirewallRules[1].Position)
+	assert.Equal(t, "22", rules.FirewallRules[0].DestinationPortStart)
+
+	floating, err := svc.AssignIPAddress(ctx, &request.AssignIPAddressRequest{Family: upcloud.IPAddressFamilyIPv4, Floating: upcloud.True, Zone: "fi-hel1"})
+	require.NoError(t, err)
+	assert.Empty(t, floating.ServerUUID)
+
+	mac := details.Networking.Interfaces[0].MAC
+	floating, err = svc.ModifyIPAddress(ctx, &request.ModifyIPAddressRequest{IPAddress: floating.Address, MAC: mac})
+	require.NoError(t, err)
+	assert.Equal(t, server.UUID, floating.ServerUUID)
+	details, err = svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Len(t, details.Networking.Interfaces[0].IPAddresses, 2)
+
+	floating, err = svc.ModifyIPAddress(ctx, &request.ModifyIPAddressRequest{IPAddress: floating.Address})
+	require.NoError(t, err)
+	assert.Empty(t, floating.MAC)
+	require.NoError(t, svc.ReleaseIPAddress(ctx, &request.ReleaseIPAddressRequest{IPAddress: floating.Address}))
+}
+
+func TestServerGroup(t *testing.T) {
+	api := New()
+	defer api.Close()
+	svc := api.Service()
+	ctx := context.Background()
+
+	first := createTestServer(t, svc)
+	second := createTestServer(t, svc)
+	api.mu.Lock()
+	api.servers[second.UUID].details.Host = first.Host
+	api.mu.Unlock()
+
+	group, err := svc.CreateServerGroup(ctx, &request.CreateServerGroupRequest{
+		Title:              "group",
+		AntiAffinityPolicy: upcloud.ServerGroupAntiAffinityPolicyStrict,
+		Members:            upcloud.ServerUUIDSlice{first.UUID, second.UUID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerUUIDSlice{first.UUID, second.UUID}, group.Members)
+	assert.Equal(t, []upcloud.ServerGroupMemberAntiAffinityStatus{
+		{ServerUUID: first.UUID, Status: upcloud.ServerAntiAffinityStatusMet},
+		{ServerUUID: second.UUID, Status: upcloud.ServerAntiAffinityStatusUnmet},
+	}, group.AntiAffinityStatus)
+	details, err := svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: second.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, group.UUID, details.ServerGroup)
+
+	// Starting the server again places it on another host
+	_, err = svc.StopServer(ctx, &request.StopServerRequest{UUID: second.UUID})
+	require.NoError(t, err)
+	_, err = svc.StartServer(ctx, &request.StartServerRequest{UUID: second.UUID})
+	require.NoError(t, err)
+	group, err = svc.GetServerGroup(ctx, &request.GetServerGroupRequest{UUID: group.UUID})
+	require.NoError(t, err)
+	require.Len(t, group.AntiAffinityStatus, 2)
+	assert.Equal(t, upcloud.ServerAntiAffinityStatusMet, group.AntiAffinityStatus[1].Status)
+
+	members := upcloud.ServerUUIDSlice{first.UUID}
+	group, err = svc.ModifyServerGroup(ctx, &request.ModifyServerGroupRequest{UUID: group.UUID, Members: &members})
+	require.NoError(t, err)
+	assert.Equal(t, members, group.Members)
+	assert.Equal(t, upcloud.ServerGroupAntiAffinityPolicyStrict, group.AntiAffinityPolicy)
+
+	require.NoError(t, svc.DeleteServerGroup(ctx, &request.DeleteServerGroupRequest{UUID: group.UUID}))
+	details, err = svc.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: first.UUID})
+	require.NoError(t, err)
+	assert.Empty(t, details.ServerGroup)
+}
+
+func TestLoadBalancer(t *testing.T) {
+	api := New()
+	defer api.Close()
+	svc := api.Service()
+	ctx := context.Background()
+
+	lb, err := svc.CreateLoadBalancer(ctx, &request.CreateLoadBalancerRequest{
+		Name:      "lb",
+		Plan:      "development",
+		Zone:      "fi-hel1",
+		Networks:  []request.LoadBalancerNetwork{{Name: "public", Type: upcloud.LoadBalancerNetworkTypePublic, Family: upcloud.LoadBalancerAddressFamilyIPv4}},
+		Backends:  []request.LoadBalancerBackend{},
+		Resolvers: []request.LoadBalancerResolver{},
+		Frontends: []request.LoadBalancerFrontend{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.LoadBalancerOperationalStatePending, lb.OperationalState)
+
+	lb, err = svc.GetLoadBalancer(ctx, &request.GetLoadBalancerRequest{UUID: lb.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.LoadBalancerOperationalStateRunning, lb.OperationalState)
+
+	_, err = svc.CreateLoadBalancerBackend(ctx, &request.CreateLoadBalancerBackendRequest{ServiceUUID: lb.UUID, Backend: request.LoadBalancerBackend{Name: "be", Members: []request.LoadBalancerBackendMember{}}})
+	require.NoError(t, err)
+	member, err := svc.CreateLoadBalancerBackendMember(ctx, &request.CreateLoadBalancerBackendMemberRequest{
+		ServiceUUID: lb.UUID,
+		BackendName: "be",
+		Member:      request.LoadBalancerBackendMember{Name: "m1", IP: "10.0.0.10", Port: 80, Weight: 100, MaxSessions: 1000, Type: upcloud.LoadBalancerBackendMemberTypeStatic, Enabled: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 80, member.Port)
+
+	member, err = svc.ModifyLoadBalancerBackendMember(ctx, &request.ModifyLoadBalancerBackendMemberRequest{
+		ServiceUUID: lb.UUID,
+		BackendName: "be",
+		Name:        "m1",
+		Member:      request.ModifyLoadBalancerBackendMember{Port: 8080},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 8080, member.Port)
+	assert.Equal(t, "10.0.0.10", member.IP)
+
+	lb, err = svc.GetLoadBalancer(ctx, &request.GetLoadBalancerRequest{UUID: lb.UUID})
+	require.NoError(t, err)
+	require.Len(t, lb.Backends, 1)
+	require.Len(t, lb.Backends[0].Members, 1)
+
+	require.NoError(t, svc.DeleteLoadBalancer(ctx, &request.DeleteLoadBalancerRequest{UUID: lb.UUID}))
+	_, err = svc.GetLoadBalancer(ctx, &request.GetLoadBalancerRequest{UUID: lb.UUID})
+	var problem *upcloud.Problem
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+}
+
+func TestAuthentication(t *testing.T) {
+	api := New()
+	defer api.Close()
+
+	svc := service.New(client.New(Username, "invalid", client.WithBaseURL(api.URL)))
+	_, err := svc.GetAccount(context.Background())
+	var problem *upcloud.Problem
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, http.StatusUnauthorized, problem.Status)
+}
@@ -0,0 +1,137 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+// firewallRule has the fields of upcloud.FirewallRule without its custom unmarshaller so that rules can be decoded
+// from request bodies.
+type firewallRule upcloud.FirewallRule
+
+func (s *Server) registerFirewallRoutes() {
+	s.handle(http.MethodGet, "/server/{uuid}/firewall_rule", s.getFirewallRules)
+	s.handle(http.MethodPost, "/server/{uuid}/firewall_rule", s.createFirewallRule)
+	s.handle(http.MethodPut, "/server/{uuid}/firewall_rule", s.replaceFirewallRules)
+	s.handle(http.MethodGet, "/server/{uuid}/firewall_rule/{position}", s.getFirewallRule)
+	s.handle(http.MethodDelete, "/server/{uuid}/firewall_rule/{position}", s.deleteFirewallRule)
+}
+
+func (s *Server) getFirewallRules(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, wrapList("firewall_rules", "firewall_rule", e.firewallRules))
+}
+
+func (s *Server) getFirewallRule(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if i := findFirewallRule(w, e, p["position"]); i >= 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"firewall_rule": encode(e.firewallRules[i])})
+	}
+}
+
+func (s *Server) createFirewallRule(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	var req struct {
+		FirewallRule firewallRule `json:"firewall_rule"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	rule := upcloud.FirewallRule(req.FirewallRule)
+	if err := validateFirewallRule(rule); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	position := rule.Position
+	if position <= 0 || position > len(e.firewallRules) {
+		position = len(e.firewallRules) + 1
+	}
+	rules := append(e.firewallRules[:position-1:position-1], rule)
+	e.firewallRules = append(rules, e.firewallRules[position-1:]...)
+	renumberFirewallRules(e.firewallRules)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"firewall_rule": encode(e.firewallRules[position-1])})
+}
+
+func (s *Server) replaceFirewallRules(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	var req struct {
+		FirewallRules struct {
+			FirewallRule []firewallRule `json:"firewall_rule"`
+		} `json:"firewall_rules"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	rules := make([]upcloud.FirewallRule, 0, len(req.FirewallRules.FirewallRule))
+	for _, rule := range req.FirewallRules.FirewallRule {
+		if err := validateFirewallRule(upcloud.FirewallRule(rule)); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		rules = append(rules, upcloud.FirewallRule(rule))
+	}
+	renumberFirewallRules(rules)
+	e.firewallRules = rules
+	writeNoContent(w)
+}
+
+func (s *Server) deleteFirewallRule(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if i := findFirewallRule(w, e, p["position"]); i >= 0 {
+		e.firewallRules = append(e.firewallRules[:i:i], e.firewallRules[i+1:]...)
+		renumberFirewallRules(e.firewallRules)
+		writeNoContent(w)
+	}
+}
+
+func findFirewallRule(w http.ResponseWriter, e *serverEntry, position string) int {
+	i, err := strconv.Atoi(position)
+	if err != nil || i < 1 || i > len(e.firewallRules) {
+		writeError(w, http.StatusNotFound, "FIREWALL_RULE_NOT_FOUND", fmt.Sprintf("The server does not have a firewall rule in position %s.", position))
+		return -1
+	}
+	return i - 1
+}
+
+func validateFirewallRule(rule upcloud.FirewallRule) error {
+	switch rule.Direction {
+	case upcloud.FirewallRuleDirectionIn, upcloud.FirewallRuleDirectionOut:
+	default:
+		return fmt.Errorf("invalid firewall rule direction %q", rule.Direction)
+	}
+	switch rule.Action {
+	case upcloud.FirewallRuleActionAccept, upcloud.FirewallRuleActionReject, upcloud.FirewallRuleActionDrop:
+	default:
+		return fmt.Errorf("invalid firewall rule action %q", rule.Action)
+	}
+	return nil
+}
+
+func renumberFirewallRules(rules []upcloud.FirewallRule) {
+	for i := range rules {
+		rules[i].Position = i + 1
+	}
+}
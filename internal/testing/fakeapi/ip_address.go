@@ -0,0 +1,223 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+)
+
+func (s *Server) registerIPAddressRoutes() {
+	s.handle(http.MethodGet, "/ip_address", s.getIPAddresses)
+	s.handle(http.MethodPost, "/ip_address", s.assignIPAddress)
+	s.handle(http.MethodGet, "/ip_address/{address}", s.getIPAddress)
+	s.handle(http.MethodPatch, "/ip_address/{address}", s.modifyIPAddress)
+	s.handle(http.MethodDelete, "/ip_address/{address}", s.releaseIPAddress)
+}
+
+func (s *Server) lookupIPAddress(w http.ResponseWriter, address string) *upcloud.IPAddress {
+	ip, ok := s.ipAddresses[address]
+	if !ok {
+		writeError(w, http.StatusNotFound, "IP_ADDRESS_NOT_FOUND", fmt.Sprintf("The IP address %s does not exist.", address))
+		return nil
+	}
+	return ip
+}
+
+func (s *Server) getIPAddresses(w http.ResponseWriter, _ *http.Request, _ params) {
+	addresses := make([]string, 0, len(s.ipAddresses))
+	for address := range s.ipAddresses {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	ips := make([]upcloud.IPAddress, 0, len(addresses))
+	for _, address := range addresses {
+		ips = append(ips, *s.ipAddresses[address])
+	}
+	writeJSON(w, http.StatusOK, wrapList("ip_addresses", "ip_address", ips))
+}
+
+func (s *Server) getIPAddress(w http.ResponseWriter, _ *http.Request, p params) {
+	if ip := s.lookupIPAddress(w, p["address"]); ip != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ip_address": encode(*ip)})
+	}
+}
+
+func (s *Server) assignIPAddress(w http.ResponseWriter, r *http.Request, _ params) {
+	var req struct {
+		IPAddress request.AssignIPAddressRequest `json:"ip_address"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	a := req.IPAddress
+	if a.Access == "" {
+		a.Access = upcloud.IPAddressAccessPublic
+	}
+	if a.Family == "" {
+		a.Family = upcloud.IPAddressFamilyIPv4
+	}
+
+	var (
+		e     *serverEntry
+		iface *upcloud.ServerInterface
+	)
+	switch {
+	case a.MAC != "":
+		if e, iface = s.findInterfaceByMAC(a.MAC); iface == nil {
+			writeError(w, http.StatusNotFound, "MAC_NOT_FOUND", fmt.Sprintf("No interface has the MAC address %s.", a.MAC))
+			return
+		}
+	case a.ServerUUID != "":
+		if e = s.lookupServer(w, a.ServerUUID); e == nil {
+			return
+		}
+		for i := range e.details.Networking.Interfaces {
+			if e.details.Networking.Interfaces[i].Type == a.Access {
+				iface = &e.details.Networking.Interfaces[i]
+				break
+			}
+		}
+		if iface == nil {
+			writeError(w, http.StatusConflict, "INTERFACE_NOT_FOUND", fmt.Sprintf("The server does not have a %s interface.", a.Access))
+			return
+		}
+	}
+
+	ip := &upcloud.IPAddress{
+		Access:     a.Access,
+		Family:     a.Family,
+		Floating:   a.Floating,
+		PartOfPlan: upcloud.False,
+		Zone:       a.Zone,
+	}
+	if ip.Floating == upcloud.Empty {
+		ip.Floating = upcloud.False
+	}
+	if e != nil {
+		ip.Zone = e.details.Zone
+	}
+	switch {
+	case ip.Zone == "":
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Either zone, server or MAC address is required.")
+		return
+	case ip.Floating == upcloud.True && (ip.Access != upcloud.IPAddressAccessPublic || ip.Family != upcloud.IPAddressFamilyIPv4):
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Floating IP addresses must be public IPv4 addresses.")
+		return
+	case ip.Floating != upcloud.True && e == nil:
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Server is required for non-floating IP addresses.")
+		return
+	}
+
+	address, err := s.allocateAddress(s.zoneNetwork(ip.Zone, ip.Access), ip.Family, "")
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	ip.Address = address
+	ip.PTRRecord = fmt.Sprintf("%s.%s.upcloud.host", address, ip.Zone)
+	s.ipAddresses[address] = ip
+	if iface != nil {
+		s.attachIPAddress(ip, e, iface)
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"ip_address": encode(*ip)})
+}
+
+func (s *Server) modifyIPAddress(w http.ResponseWriter, r *http.Request, p params) {
+	ip := s.lookupIPAddress(w, p["address"])
+	if ip == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req struct {
+		IPAddress request.ModifyIPAddressRequest `json:"ip_address"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	setIfNotEmpty(&ip.PTRRecord, req.IPAddress.PTRRecord)
+	if _, ok := rawField(body, "ip_address", "mac"); ok && req.IPAddress.MAC != ip.MAC {
+		if ip.Floating != upcloud.True {
+			writeError(w, http.StatusBadRequest, "IP_ADDRESS_NOT_FLOATING", "Only floating IP addresses can be moved between servers.")
+			return
+		}
+		var (
+			e     *serverEntry
+			iface *upcloud.ServerInterface
+		)
+		if req.IPAddress.MAC != "" {
+			if e, iface = s.findInterfaceByMAC(req.IPAddress.MAC); iface == nil || e.details.Zone != ip.Zone {
+				writeError(w, http.StatusNotFound, "MAC_NOT_FOUND", fmt.Sprintf("No interface in zone %s has the MAC address %s.", ip.Zone, req.IPAddress.MAC))
+				return
+			}
+		}
+		s.detachIPAddress(ip)
+		if iface != nil {
+			s.attachIPAddress(ip, e, iface)
+		}
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"ip_address": encode(*ip)})
+}
+
+func (s *Server) releaseIPAddress(w http.ResponseWriter, _ *http.Request, p params) {
+	ip := s.lookupIPAddress(w, p["address"])
+	if ip == nil {
+		return
+	}
+	if ip.PartOfPlan == upcloud.True {
+		writeError(w, http.StatusConflict, "IP_ADDRESS_PART_OF_PLAN", "The IP address is part of the server plan and cannot be released.")
+		return
+	}
+	s.detachIPAddress(ip)
+	delete(s.ipAddresses, ip.Address)
+	writeNoContent(w)
+}
+
+func (s *Server) findInterfaceByMAC(mac string) (*serverEntry, *upcloud.ServerInterface) {
+	for _, e := range s.servers {
+		for i := range e.details.Networking.Interfaces {
+			if e.details.Networking.Interfaces[i].MAC == mac {
+				return e, &e.details.Networking.Interfaces[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) attachIPAddress(ip *upcloud.IPAddress, e *serverEntry, iface *upcloud.ServerInterface) {
+	ip.ServerUUID = e.details.UUID
+	ip.MAC = iface.MAC
+	iface.IPAddresses = append(iface.IPAddresses, upcloud.IPAddress{
+		Address:  ip.Address,
+		Family:   ip.Family,
+		Floating: ip.Floating,
+	})
+	e.details.IPAddresses = append(e.details.IPAddresses, upcloud.IPAddress{
+		Access:   ip.Access,
+		Address:  ip.Address,
+		Family:   ip.Family,
+		Floating: ip.Floating,
+	})
+}
+
+func (s *Server) detachIPAddress(ip *upcloud.IPAddress) {
+	if e, iface := s.findInterfaceByMAC(ip.MAC); iface != nil {
+		iface.IPAddresses = removeIPAddress(iface.IPAddresses, ip.Address)
+		e.details.IPAddresses = removeIPAddress(e.details.IPAddresses, ip.Address)
+	}
+	ip.ServerUUID, ip.MAC = "", ""
+}
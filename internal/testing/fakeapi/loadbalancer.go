@@ -0,0 +1,528 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+// loadBalancerDeleted is the target state of a load balancer that is being deleted. The load balancer is removed
+// once the transition completes.
+const loadBalancerDeleted = "deleted"
+
+type loadBalancerEntry struct {
+	details    upcloud.LoadBalancer
+	transition *transition
+}
+
+func (s *Server) registerLoadBalancerRoutes() {
+	s.handle(http.MethodGet, "/load-balancer", s.getLoadBalancers)
+	s.handle(http.MethodPost, "/load-balancer", s.createLoadBalancer)
+	s.handle(http.MethodGet, "/load-balancer/plans", s.getLoadBalancerPlans)
+	s.handle(http.MethodGet, "/load-balancer/certificate-bundles", s.getCertificateBundles)
+	s.handle(http.MethodPost, "/load-balancer/certificate-bundles", s.createCertificateBundle)
+	s.handle(http.MethodGet, "/load-balancer/certificate-bundles/{uuid}", s.getCertificateBundle)
+	s.handle(http.MethodPatch, "/load-balancer/certificate-bundles/{uuid}", s.modifyCertificateBundle)
+	s.handle(http.MethodDelete, "/load-balancer/certificate-bundles/{uuid}", s.deleteCertificateBundle)
+	s.handle(http.MethodGet, "/load-balancer/{uuid}", s.getLoadBalancer)
+	s.handle(http.MethodPatch, "/load-balancer/{uuid}", s.modifyLoadBalancer)
+	s.handle(http.MethodDelete, "/load-balancer/{uuid}", s.deleteLoadBalancer)
+	s.handle(http.MethodPatch, "/load-balancer/{uuid}/networks/{name}", s.modifyLoadBalancerNetwork)
+
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/backends", "BACKEND",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, _ params) *[]upcloud.LoadBalancerBackend {
+			return &lb.Backends
+		},
+		func(b *upcloud.LoadBalancerBackend) *string { return &b.Name },
+	)
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/backends/{backend}/members", "MEMBER",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, p params) *[]upcloud.LoadBalancerBackendMember {
+			if i := findLoadBalancerChild(w, lb.Backends, p["backend"], "BACKEND", func(b *upcloud.LoadBalancerBackend) *string { return &b.Name }); i >= 0 {
+				return &lb.Backends[i].Members
+			}
+			return nil
+		},
+		func(m *upcloud.LoadBalancerBackendMember) *string { return &m.Name },
+	)
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/resolvers", "RESOLVER",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, _ params) *[]upcloud.LoadBalancerResolver {
+			return &lb.Resolvers
+		},
+		func(r *upcloud.LoadBalancerResolver) *string { return &r.Name },
+	)
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/frontends", "FRONTEND",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, _ params) *[]upcloud.LoadBalancerFrontend {
+			return &lb.Frontends
+		},
+		func(f *upcloud.LoadBalancerFrontend) *string { return &f.Name },
+	)
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/frontends/{frontend}/rules", "RULE",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, p params) *[]upcloud.LoadBalancerFrontendRule {
+			if i := findLoadBalancerChild(w, lb.Frontends, p["frontend"], "FRONTEND", func(f *upcloud.LoadBalancerFrontend) *string { return &f.Name }); i >= 0 {
+				return &lb.Frontends[i].Rules
+			}
+			return nil
+		},
+		func(r *upcloud.LoadBalancerFrontendRule) *string { return &r.Name },
+	)
+	registerLoadBalancerChildRoutes(s, "/load-balancer/{uuid}/frontends/{frontend}/tls-configs", "TLS_CONFIG",
+		func(w http.ResponseWriter, lb *upcloud.LoadBalancer, p params) *[]upcloud.LoadBalancerFrontendTLSConfig {
+			if i := findLoadBalancerChild(w, lb.Frontends, p["frontend"], "FRONTEND", func(f *upcloud.LoadBalancerFrontend) *string { return &f.Name }); i >= 0 {
+				return &lb.Frontends[i].TLSConfigs
+			}
+			return nil
+		},
+		func(c *upcloud.LoadBalancerFrontendTLSConfig) *string { return &c.Name },
+	)
+}
+
+// loadBalancer returns the load balancer with the given UUID after applying its pending state transition, or nil if
+// the load balancer does not exist.
+func (s *Server) loadBalancer(uuid string) *loadBalancerEntry {
+	e, ok := s.loadBalancers[uuid]
+	if !ok {
+		return nil
+	}
+	if state, done := e.transition.advance(string(e.details.OperationalState)); done {
+		e.transition = nil
+		if state == loadBalancerDeleted {
+			delete(s.loadBalancers, uuid)
+			return nil
+		}
+		e.details.OperationalState = upcloud.LoadBalancerOperationalState(state)
+	}
+	return e
+}
+
+func (s *Server) lookupLoadBalancer(w http.ResponseWriter, uuid string) *loadBalancerEntry {
+	e := s.loadBalancer(uuid)
+	if e == nil {
+		writeProblem(w, http.StatusNotFound, "SERVICE_NOT_FOUND", fmt.Sprintf("Load balancer %s not found.", uuid))
+	}
+	return e
+}
+
+func (s *Server) getLoadBalancers(w http.ResponseWriter, r *http.Request, _ params) {
+	uuids := make([]string, 0, len(s.loadBalancers))
+	for uuid := range s.loadBalancers {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	loadBalancers := make([]upcloud.LoadBalancer, 0, len(uuids))
+	if !isSubsequentPage(r) {
+		for _, uuid := range uuids {
+			if e := s.loadBalancer(uuid); e != nil {
+				loadBalancers = append(loadBalancers, e.details)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, loadBalancers)
+}
+
+func (s *Server) getLoadBalancer(w http.ResponseWriter, _ *http.Request, p params) {
+	if e := s.lookupLoadBalancer(w, p["uuid"]); e != nil {
+		writeJSON(w, http.StatusOK, e.details)
+	}
+}
+
+func (s *Server) createLoadBalancer(w http.ResponseWriter, r *http.Request, _ params) {
+	var lb upcloud.LoadBalancer
+	if err := decodeBody(r, &lb); err != nil {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if lb.Name == "" || lb.Plan == "" || lb.Zone == "" {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", "Name, plan and zone are required.")
+		return
+	}
+	if !s.isLoadBalancerPlan(lb.Plan) {
+		writeProblem(w, http.StatusBadRequest, "PLAN_NOT_FOUND", fmt.Sprintf("Plan %s not found.", lb.Plan))
+		return
+	}
+	for _, n := range lb.Networks {
+		if n.Type == upcloud.LoadBalancerNetworkTypePrivate {
+			if _, ok := s.networks[n.UUID]; !ok {
+				writeProblem(w, http.StatusBadRequest, "NETWORK_NOT_FOUND", fmt.Sprintf("Network %s not found.", n.UUID))
+				return
+			}
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	lb.UUID = newUUID(0x0a)
+	lb.CreatedAt, lb.UpdatedAt = now, now
+	lb.OperationalState = upcloud.LoadBalancerOperationalStatePending
+	if lb.ConfiguredStatus == "" {
+		lb.ConfiguredStatus = upcloud.LoadBalancerConfiguredStatusStarted
+	}
+	for i := range lb.Networks {
+		n := &lb.Networks[i]
+		n.CreatedAt, n.UpdatedAt = now, now
+		if n.Type == upcloud.LoadBalancerNetworkTypePublic {
+			n.DNSName = fmt.Sprintf("lb-%s-%d.upcloudlb.com", lb.UUID[:8], i+1)
+		}
+	}
+	if lb.Frontends == nil {
+		lb.Frontends = []upcloud.LoadBalancerFrontend{}
+	}
+	if lb.Backends == nil {
+		lb.Backends = []upcloud.LoadBalancerBackend{}
+	}
+	if lb.Resolvers == nil {
+		lb.Resolvers = []upcloud.LoadBalancerResolver{}
+	}
+	if lb.Labels == nil {
+		lb.Labels = []upcloud.Label{}
+	}
+
+	s.loadBalancers[lb.UUID] = &loadBalancerEntry{
+		details:    lb,
+		transition: s.newTransition(string(upcloud.LoadBalancerOperationalStateRunning)),
+	}
+	writeJSON(w, http.StatusCreated, lb)
+}
+
+func (s *Server) modifyLoadBalancer(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupLoadBalancer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	updated := e.details
+	if err := mergePatch(&updated, r); err != nil {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if !s.isLoadBalancerPlan(updated.Plan) {
+		writeProblem(w, http.StatusBadRequest, "PLAN_NOT_FOUND", fmt.Sprintf("Plan %s not found.", updated.Plan))
+		return
+	}
+	updated.UUID, updated.Zone = e.details.UUID, e.details.Zone
+	updated.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	e.details = updated
+	writeJSON(w, http.StatusOK, e.details)
+}
+
+func (s *Server) deleteLoadBalancer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupLoadBalancer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	e.details.OperationalState = upcloud.LoadBalancerOperationalStateDeleteService
+	e.transition = s.newTransition(loadBalancerDeleted)
+	writeNoContent(w)
+}
+
+func (s *Server) modifyLoadBalancerNetwork(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupLoadBalancer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	for i := range e.details.Networks {
+		n := &e.details.Networks[i]
+		if n.Name != p["name"] {
+			continue
+		}
+		updated := *n
+		if err := mergePatch(&updated, r); err != nil {
+			writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		updated.UUID, updated.Type, updated.Family = n.UUID, n.Type, n.Family
+		updated.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+		*n = updated
+		writeJSON(w, http.StatusOK, *n)
+		return
+	}
+	writeProblem(w, http.StatusNotFound, "NETWORK_NOT_FOUND", fmt.Sprintf("Network %s not found.", p["name"]))
+}
+
+func (s *Server) getLoadBalancerPlans(w http.ResponseWriter, r *http.Request, _ params) {
+	plans := []upcloud.LoadBalancerPlan{}
+	if !isSubsequentPage(r) {
+		plans = append(plans,
+			upcloud.LoadBalancerPlan{Name: "development", PerServerMaxSessions: 1000, ServerNumber: 1},
+			upcloud.LoadBalancerPlan{Name: "production-small", PerServerMaxSessions: 50000, ServerNumber: 2},
+		)
+	}
+	writeJSON(w, http.StatusOK, plans)
+}
+
+func (s *Server) isLoadBalancerPlan(name string) bool {
+	return name == "development" || name == "production-small"
+}
+
+func (s *Server) getCertificateBundles(w http.ResponseWriter, r *http.Request, _ params) {
+	uuids := make([]string, 0, len(s.certificateBundles))
+	for uuid := range s.certificateBundles {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	bundles := make([]upcloud.LoadBalancerCertificateBundle, 0, len(uuids))
+	if !isSubsequentPage(r) {
+		for _, uuid := range uuids {
+			bundles = append(bundles, *s.certificateBundles[uuid])
+		}
+	}
+	writeJSON(w, http.StatusOK, bundles)
+}
+
+func (s *Server) lookupCertificateBundle(w http.ResponseWriter, uuid string) *upcloud.LoadBalancerCertificateBundle {
+	b, ok := s.certificateBundles[uuid]
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "CERTIFICATE_BUNDLE_NOT_FOUND", fmt.Sprintf("Certificate bundle %s not found.", uuid))
+		return nil
+	}
+	return b
+}
+
+func (s *Server) getCertificateBundle(w http.ResponseWriter, _ *http.Request, p params) {
+	if b := s.lookupCertificateBundle(w, p["uuid"]); b != nil {
+		writeJSON(w, http.StatusOK, *b)
+	}
+}
+
+func (s *Server) createCertificateBundle(w http.ResponseWriter, r *http.Request, _ params) {
+	var b upcloud.LoadBalancerCertificateBundle
+	if err := decodeBody(r, &b); err != nil {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if b.Name == "" || b.Type == "" {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", "Name and type are required.")
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	b.UUID = newUUID(0x0a)
+	b.CreatedAt, b.UpdatedAt = now, now
+	if b.Type == upcloud.LoadBalancerCertificateBundleTypeDynamic {
+		b.OperationalState = upcloud.LoadBalancerCertificateBundleOperationalStateIdle
+		b.NotBefore, b.NotAfter = now, now.AddDate(0, 3, 0)
+	}
+	s.certificateBundles[b.UUID] = &b
+	writeJSON(w, http.StatusCreated, b)
+}
+
+func (s *Server) modifyCertificateBundle(w http.ResponseWriter, r *http.Request, p params) {
+	b := s.lookupCertificateBundle(w, p["uuid"])
+	if b == nil {
+		return
+	}
+	updated := *b
+	if err := mergePatch(&updated, r); err != nil {
+		writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	updated.UUID, updated.Type = b.UUID, b.Type
+	updated.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	*b = updated
+	writeJSON(w, http.StatusOK, *b)
+}
+
+func (s *Server) deleteCertificateBundle(w http.ResponseWriter, _ *http.Request, p params) {
+	b := s.lookupCertificateBundle(w, p["uuid"])
+	if b == nil {
+		return
+	}
+	for _, e := range s.loadBalancers {
+		for _, f := range e.details.Frontends {
+			for _, c := range f.TLSConfigs {
+				if c.CertificateBundleUUID == b.UUID {
+					writeProblem(w, http.StatusConflict, "CERTIFICATE_BUNDLE_IN_USE", "Certificate bundle is in use.")
+					return
+				}
+			}
+		}
+	}
+	delete(s.certificateBundles, b.UUID)
+	writeNoContent(w)
+}
+
+// registerLoadBalancerChildRoutes registers list, create, read, modify, replace and delete routes for the objects
+// that belong to a load balancer, e.g. backends and frontend rules. list returns the collection the objects are
+// stored in, or nil after writing an error response if the parent of the collection does not exist.
+func registerLoadBalancerChildRoutes[T any](s *Server, base, kind string, list func(http.ResponseWriter, *upcloud.LoadBalancer, params) *[]T, name func(*T) *string) {
+	collection := func(w http.ResponseWriter, p params) (*loadBalancerEntry, *[]T) {
+		e := s.lookupLoadBalancer(w, p["uuid"])
+		if e == nil {
+			return nil, nil
+		}
+		items := list(w, &e.details, p)
+		if items == nil {
+			return nil, nil
+		}
+		return e, items
+	}
+
+	s.handle(http.MethodGet, base, func(w http.ResponseWriter, _ *http.Request, p params) {
+		if _, items := collection(w, p); items != nil {
+			out := *items
+			if out == nil {
+				out = []T{}
+			}
+			writeJSON(w, http.StatusOK, out)
+		}
+	})
+
+	s.handle(http.MethodPost, base, func(w http.ResponseWriter, r *http.Request, p params) {
+		e, items := collection(w, p)
+		if items == nil {
+			return
+		}
+		var item T
+		if err := decodeBody(r, &item); err != nil {
+			writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if *name(&item) == "" {
+			writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", "Name is required.")
+			return
+		}
+		for i := range *items {
+			if *name(&(*items)[i]) == *name(&item) {
+				writeProblem(w, http.StatusConflict, kind+"_EXISTS", fmt.Sprintf("Name %s is already in use.", *name(&item)))
+				return
+			}
+		}
+		setTimestamps(&item, true)
+		*items = append(*items, item)
+		e.details.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+		writeJSON(w, http.StatusCreated, item)
+	})
+
+	s.handle(http.MethodGet, base+"/{name}", func(w http.ResponseWriter, _ *http.Request, p params) {
+		if _, items := collection(w, p); items != nil {
+			if i := findLoadBalancerChild(w, *items, p["name"], kind, name); i >= 0 {
+				writeJSON(w, http.StatusOK, (*items)[i])
+			}
+		}
+	})
+
+	update := func(replace bool) handlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, p params) {
+			_, items := collection(w, p)
+			if items == nil {
+				return
+			}
+			i := findLoadBalancerChild(w, *items, p["name"], kind, name)
+			if i < 0 {
+				return
+			}
+
+			var updated T
+			if !replace {
+				updated = (*items)[i]
+			}
+			if err := mergePatch(&updated, r); err != nil {
+				writeProblem(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+				return
+			}
+			if *name(&updated) == "" {
+				*name(&updated) = p["name"]
+			}
+			setTimestamps(&updated, replace)
+			(*items)[i] = updated
+			writeJSON(w, http.StatusOK, updated)
+		}
+	}
+	s.handle(http.MethodPatch, base+"/{name}", update(false))
+	s.handle(http.MethodPut, base+"/{name}", update(true))
+
+	s.handle(http.MethodDelete, base+"/{name}", func(w http.ResponseWriter, _ *http.Request, p params) {
+		_, items := collection(w, p)
+		if items == nil {
+			return
+		}
+		if i := findLoadBalancerChild(w, *items, p["name"], kind, name); i >= 0 {
+			*items = append((*items)[:i:i], (*items)[i+1:]...)
+			writeNoContent(w)
+		}
+	})
+}
+
+func findLoadBalancerChild[T any](w http.ResponseWriter, items []T, value, kind string, name func(*T) *string) int {
+	for i := range items {
+		if *name(&items[i]) == value {
+			return i
+		}
+	}
+	writeProblem(w, http.StatusNotFound, kind+"_NOT_FOUND", fmt.Sprintf("Name %s not found.", value))
+	return -1
+}
+
+// mergePatch applies the JSON object in the body of r on top of v. Nested objects are merged and other values,
+// including lists, are replaced.
+func mergePatch(v interface{}, r *http.Request) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return err
+	}
+
+	current, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+	mergeObjects(doc, patch)
+
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// Decode into a zero value so that fields removed by the patch are cleared
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	return json.Unmarshal(merged, v)
+}
+
+func mergeObjects(dst, patch map[string]interface{}) {
+	for k, pv := range patch {
+		if pv == nil {
+			delete(dst, k)
+			continue
+		}
+		if po, ok := pv.(map[string]interface{}); ok {
+			if do, ok := dst[k].(map[string]interface{}); ok {
+				mergeObjects(do, po)
+				continue
+			}
+		}
+		dst[k] = pv
+	}
+}
+
+// setTimestamps sets the UpdatedAt field of v and, if created is true, the CreatedAt field.
+func setTimestamps(v interface{}, created bool) {
+	now := reflect.ValueOf(time.Now().UTC().Truncate(time.Second))
+	rv := reflect.ValueOf(v).Elem()
+	if f := rv.FieldByName("UpdatedAt"); f.IsValid() {
+		f.Set(now)
+	}
+	if f := rv.FieldByName("CreatedAt"); created && f.IsValid() {
+		f.Set(now)
+	}
+}
+
+// isSubsequentPage reports whether r requests other than the first page of a paginated list. The fake API returns
+// all items on the first page.
+func isSubsequentPage(r *http.Request) bool {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	return offset > 0
+}
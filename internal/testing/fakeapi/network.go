@@ -0,0 +1,607 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+type interfaceRequest struct {
+	Interface createServerInterface `json:"interface"`
+}
+
+func (s *Server) registerNetworkRoutes() {
+	s.handle(http.MethodGet, "/network", s.getNetworks)
+	s.handle(http.MethodPost, "/network", s.createNetwork)
+	s.handle(http.MethodGet, "/network/{uuid}", s.getNetwork)
+	s.handle(http.MethodPut, "/network/{uuid}", s.modifyNetwork)
+	s.handle(http.MethodDelete, "/network/{uuid}", s.deleteNetwork)
+	s.handle(http.MethodGet, "/router", s.getRouters)
+	s.handle(http.MethodPost, "/router", s.createRouter)
+	s.handle(http.MethodGet, "/router/{uuid}", s.getRouter)
+	s.handle(http.MethodPut, "/router/{uuid}", s.modifyRouter)
+	s.handle(http.MethodDelete, "/router/{uuid}", s.deleteRouter)
+	s.handle(http.MethodGet, "/server/{uuid}/networking", s.getServerNetworking)
+	s.handle(http.MethodPost, "/server/{uuid}/networking/interface", s.createInterface)
+	s.handle(http.MethodPut, "/server/{uuid}/networking/interface/{index}", s.modifyInterface)
+	s.handle(http.MethodDelete, "/server/{uuid}/networking/interface/{index}", s.deleteInterface)
+}
+
+func (s *Server) lookupNetwork(w http.ResponseWriter, uuid string) *upcloud.Network {
+	n, ok := s.networks[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NETWORK_NOT_FOUND", fmt.Sprintf("The network %s does not exist.", uuid))
+		return nil
+	}
+	return n
+}
+
+func (s *Server) lookupRouter(w http.ResponseWriter, uuid string) *upcloud.Router {
+	rt, ok := s.routers[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ROUTER_NOT_FOUND", fmt.Sprintf("The router %s does not exist.", uuid))
+		return nil
+	}
+	return rt
+}
+
+func (s *Server) getNetworks(w http.ResponseWriter, r *http.Request, _ params) {
+	zone := r.URL.Query().Get("zone")
+
+	uuids := make([]string, 0, len(s.networks))
+	for uuid := range s.networks {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	networks := make([]upcloud.Network, 0, len(uuids))
+	for _, uuid := range uuids {
+		if n := s.networks[uuid]; zone == "" || n.Zone == zone {
+			networks = append(networks, *n)
+		}
+	}
+	writeJSON(w, http.StatusOK, wrapList("networks", "network", networks))
+}
+
+func (s *Server) getNetwork(w http.ResponseWriter, _ *http.Request, p params) {
+	if n := s.lookupNetwork(w, p["uuid"]); n != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"network": encode(*n)})
+	}
+}
+
+func (s *Server) createNetwork(w http.ResponseWriter, r *http.Request, _ params) {
+	var n upcloud.Network
+	if err := decodeBody(r, &n); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if n.Name == "" || n.Zone == "" || len(n.IPNetworks) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Name, zone and at least one IP network are required.")
+		return
+	}
+	if err := validateIPNetworks(n.IPNetworks); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	n.UUID = newUUID(0x03)
+	n.Type = upcloud.NetworkTypePrivate
+	n.Servers = upcloud.NetworkServerSlice{}
+	if n.Router != "" {
+		rt := s.lookupRouter(w, n.Router)
+		if rt == nil {
+			return
+		}
+		rt.AttachedNetworks = append(rt.AttachedNetworks, upcloud.RouterNetwork{NetworkUUID: n.UUID})
+	}
+
+	s.networks[n.UUID] = &n
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"network": encode(n)})
+}
+
+func validateIPNetworks(ipNetworks upcloud.IPNetworkSlice) error {
+	for _, ipNetwork := range ipNetworks {
+		if _, _, err := net.ParseCIDR(ipNetwork.Address); err != nil {
+			return fmt.Errorf("invalid IP network address %s", ipNetwork.Address)
+		}
+	}
+	return nil
+}
+
+func (s *Server) modifyNetwork(w http.ResponseWriter, r *http.Request, p params) {
+	n := s.lookupNetwork(w, p["uuid"])
+	if n == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req upcloud.Network
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	setIfNotEmpty(&n.Name, req.Name)
+	if len(req.IPNetworks) > 0 {
+		if err := validateIPNetworks(req.IPNetworks); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		n.IPNetworks = req.IPNetworks
+	}
+	if _, ok := rawField(body, "network", "labels"); ok {
+		n.Labels = req.Labels
+	}
+	if _, ok := rawField(body, "network", "router"); ok && req.Router != n.Router {
+		if req.Router != "" && s.lookupRouter(w, req.Router) == nil {
+			return
+		}
+		if rt, ok := s.routers[n.Router]; ok {
+			rt.AttachedNetworks = removeRouterNetwork(rt.AttachedNetworks, n.UUID)
+		}
+		if rt, ok := s.routers[req.Router]; ok {
+			rt.AttachedNetworks = append(rt.AttachedNetworks, upcloud.RouterNetwork{NetworkUUID: n.UUID})
+		}
+		n.Router = req.Router
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"network": encode(*n)})
+}
+
+func removeRouterNetwork(networks upcloud.RouterNetworkSlice, uuid string) upcloud.RouterNetworkSlice {
+	out := make(upcloud.RouterNetworkSlice, 0, len(networks))
+	for _, network := range networks {
+		if network.NetworkUUID != uuid {
+			out = append(out, network)
+		}
+	}
+	return out
+}
+
+func (s *Server) deleteNetwork(w http.ResponseWriter, _ *http.Request, p params) {
+	n := s.lookupNetwork(w, p["uuid"])
+	if n == nil {
+		return
+	}
+	if n.Type != upcloud.NetworkTypePrivate {
+		writeError(w, http.StatusForbidden, "NETWORK_FORBIDDEN", "Only private networks can be deleted.")
+		return
+	}
+	if len(n.Servers) > 0 {
+		writeError(w, http.StatusConflict, "NETWORK_CANNOT_BE_DELETED", "The network has servers attached to it.")
+		return
+	}
+	if rt, ok := s.routers[n.Router]; ok {
+		rt.AttachedNetworks = removeRouterNetwork(rt.AttachedNetworks, n.UUID)
+	}
+	delete(s.networks, n.UUID)
+	writeNoContent(w)
+}
+
+func (s *Server) getRouters(w http.ResponseWriter, _ *http.Request, _ params) {
+	uuids := make([]string, 0, len(s.routers))
+	for uuid := range s.routers {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	routers := make([]upcloud.Router, 0, len(uuids))
+	for _, uuid := range uuids {
+		routers = append(routers, *s.routers[uuid])
+	}
+	writeJSON(w, http.StatusOK, wrapList("routers", "router", routers))
+}
+
+func (s *Server) getRouter(w http.ResponseWriter, _ *http.Request, p params) {
+	if rt := s.lookupRouter(w, p["uuid"]); rt != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"router": encode(*rt)})
+	}
+}
+
+func (s *Server) createRouter(w http.ResponseWriter, r *http.Request, _ params) {
+	var rt upcloud.Router
+	if err := decodeBody(r, &rt); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if rt.Name == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Name is required.")
+		return
+	}
+
+	rt.UUID = newUUID(0x04)
+	rt.Type = "normal"
+	rt.AttachedNetworks = upcloud.RouterNetworkSlice{}
+	s.routers[rt.UUID] = &rt
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"router": encode(rt)})
+}
+
+func (s *Server) modifyRouter(w http.ResponseWriter, r *http.Request, p params) {
+	rt := s.lookupRouter(w, p["uuid"])
+	if rt == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req upcloud.Router
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	setIfNotEmpty(&rt.Name, req.Name)
+	if _, ok := rawField(body, "router", "labels"); ok {
+		rt.Labels = req.Labels
+	}
+	if _, ok := rawField(body, "router", "static_routes"); ok {
+		rt.StaticRoutes = req.StaticRoutes
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"router": encode(*rt)})
+}
+
+func (s *Server) deleteRouter(w http.ResponseWriter, _ *http.Request, p params) {
+	rt := s.lookupRouter(w, p["uuid"])
+	if rt == nil {
+		return
+	}
+	if len(rt.AttachedNetworks) > 0 {
+		writeError(w, http.StatusConflict, "ROUTER_IN_USE", "The router has networks attached to it.")
+		return
+	}
+	delete(s.routers, rt.UUID)
+	writeNoContent(w)
+}
+
+func (s *Server) getServerNetworking(w http.ResponseWriter, _ *http.Request, p params) {
+	if srv := s.lookupServer(w, p["uuid"]); srv != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"networking": encode(upcloud.Networking(srv.details.Networking))})
+	}
+}
+
+func (s *Server) createInterface(w http.ResponseWriter, r *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+	if srv.details.State != upcloud.ServerStateStopped {
+		writeError(w, http.StatusConflict, "SERVER_STATE_ILLEGAL", "The server must be stopped to add a network interface.")
+		return
+	}
+
+	var req interfaceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	iface, err := s.addInterface(&srv.details, req.Interface)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"interface": encode(iface)})
+}
+
+func (s *Server) modifyInterface(w http.ResponseWriter, r *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+	pos := s.findInterface(w, &srv.details, p["index"])
+	if pos < 0 {
+		return
+	}
+
+	var req interfaceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	iface := &srv.details.Networking.Interfaces[pos]
+	if req.Interface.Index != 0 {
+		iface.Index = req.Interface.Index
+	}
+	if req.Interface.SourceIPFiltering != upcloud.Empty {
+		iface.SourceIPFiltering = req.Interface.SourceIPFiltering
+	}
+	if req.Interface.Bootable != upcloud.Empty {
+		iface.Bootable = req.Interface.Bootable
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"interface": encode(upcloud.Interface(*iface))})
+}
+
+func (s *Server) deleteInterface(w http.ResponseWriter, _ *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+	if srv.details.State != upcloud.ServerStateStopped {
+		writeError(w, http.StatusConflict, "SERVER_STATE_ILLEGAL", "The server must be stopped to remove a network interface.")
+		return
+	}
+	pos := s.findInterface(w, &srv.details, p["index"])
+	if pos < 0 {
+		return
+	}
+
+	interfaces := srv.details.Networking.Interfaces
+	s.detachInterface(srv.details.UUID, interfaces[pos])
+	for _, ip := range interfaces[pos].IPAddresses {
+		srv.details.IPAddresses = removeIPAddress(srv.details.IPAddresses, ip.Address)
+	}
+	srv.details.Networking.Interfaces = append(interfaces[:pos:pos], interfaces[pos+1:]...)
+	writeNoContent(w)
+}
+
+func (s *Server) findInterface(w http.ResponseWriter, d *upcloud.ServerDetails, index string) int {
+	i, err := strconv.Atoi(index)
+	if err == nil {
+		for pos, iface := range d.Networking.Interfaces {
+			if iface.Index == i {
+				return pos
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "INTERFACE_NOT_FOUND", fmt.Sprintf("The server does not have an interface with index %s.", index))
+	return -1
+}
+
+// addInterface adds a network interface to server d and allocates the requested IP addresses.
+func (s *Server) addInterface(d *upcloud.ServerDetails, req createServerInterface) (upcloud.Interface, error) {
+	index := req.Index
+	if index == 0 {
+		for _, iface := range d.Networking.Interfaces {
+			if iface.Index > index {
+				index = iface.Index
+			}
+		}
+		index++
+	}
+	for _, iface := range d.Networking.Interfaces {
+		if iface.Index == index {
+			return upcloud.Interface{}, fmt.Errorf("interface index %d is already in use", index)
+		}
+	}
+
+	var network *upcloud.Network
+	switch req.Type {
+	case upcloud.NetworkTypePublic, upcloud.NetworkTypeUtility:
+		network = s.zoneNetwork(d.Zone, req.Type)
+	case upcloud.NetworkTypePrivate:
+		n, ok := s.networks[req.Network]
+		if !ok {
+			return upcloud.Interface{}, fmt.Errorf("network %s does not exist", req.Network)
+		}
+		if n.Zone != d.Zone {
+			return upcloud.Interface{}, fmt.Errorf("network %s is not in zone %s", req.Network, d.Zone)
+		}
+		network = n
+	default:
+		return upcloud.Interface{}, fmt.Errorf("unknown interface type %s", req.Type)
+	}
+
+	iface := upcloud.ServerInterface{
+		Index:             index,
+		IPAddresses:       upcloud.IPAddressSlice{},
+		MAC:               s.newMAC(),
+		Network:           network.UUID,
+		Type:              req.Type,
+		Bootable:          upcloud.False,
+		SourceIPFiltering: upcloud.True,
+	}
+	if req.Bootable != upcloud.Empty {
+		iface.Bootable = req.Bootable
+	}
+	if req.SourceIPFiltering != upcloud.Empty {
+		iface.SourceIPFiltering = req.SourceIPFiltering
+	}
+
+	requested := req.IPAddresses.IPAddress
+	if len(requested) == 0 {
+		requested = append(requested, struct {
+			Family  string `json:"family"`
+			Address string `json:"address,omitempty"`
+		}{Family: upcloud.IPAddressFamilyIPv4})
+	}
+	for _, r := range requested {
+		address, err := s.allocateAddress(network, r.Family, r.Address)
+		if err != nil {
+			return upcloud.Interface{}, err
+		}
+		ip := upcloud.IPAddress{
+			Access:     req.Type,
+			Address:    address,
+			Family:     r.Family,
+			PartOfPlan: upcloud.False,
+			ServerUUID: d.UUID,
+			MAC:        iface.MAC,
+			Floating:   upcloud.False,
+			Zone:       d.Zone,
+		}
+		if req.Type == upcloud.NetworkTypePublic && r.Family == upcloud.IPAddressFamilyIPv4 && !s.hasPlanAddress(d) {
+			ip.PartOfPlan = upcloud.True
+		}
+		if req.Type != upcloud.NetworkTypePrivate {
+			ip.PTRRecord = fmt.Sprintf("%s.%s.upcloud.host", address, d.Zone)
+			registered := ip
+			s.ipAddresses[address] = &registered
+		}
+		iface.IPAddresses = append(iface.IPAddresses, ip)
+		d.IPAddresses = append(d.IPAddresses, upcloud.IPAddress{Access: ip.Access, Address: ip.Address, Family: ip.Family})
+	}
+
+	if req.Type == upcloud.NetworkTypePrivate {
+		network.Servers = append(network.Servers, upcloud.NetworkServer{ServerUUID: d.UUID, ServerTitle: d.Title})
+	}
+
+	d.Networking.Interfaces = append(d.Networking.Interfaces, iface)
+	sort.Slice(d.Networking.Interfaces, func(i, j int) bool {
+		return d.Networking.Interfaces[i].Index < d.Networking.Interfaces[j].Index
+	})
+	return upcloud.Interface(iface), nil
+}
+
+func (s *Server) hasPlanAddress(d *upcloud.ServerDetails) bool {
+	for _, iface := range d.Networking.Interfaces {
+		for _, ip := range iface.IPAddresses {
+			if ip.PartOfPlan == upcloud.True {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detachInterface releases the addresses of iface and removes the server from the network of the interface.
+func (s *Server) detachInterface(serverUUID string, iface upcloud.ServerInterface) {
+	for _, ip := range iface.IPAddresses {
+		registered, ok := s.ipAddresses[ip.Address]
+		if !ok {
+			continue
+		}
+		if registered.Floating == upcloud.True {
+			registered.ServerUUID, registered.MAC = "", ""
+			continue
+		}
+		delete(s.ipAddresses, ip.Address)
+	}
+
+	if n, ok := s.networks[iface.Network]; ok && n.Type == upcloud.NetworkTypePrivate {
+		servers := make(upcloud.NetworkServerSlice, 0, len(n.Servers))
+		for _, srv := range n.Servers {
+			if srv.ServerUUID != serverUUID {
+				servers = append(servers, srv)
+			}
+		}
+		n.Servers = servers
+	}
+}
+
+func removeIPAddress(list upcloud.IPAddressSlice, address string) upcloud.IPAddressSlice {
+	out := make(upcloud.IPAddressSlice, 0, len(list))
+	for _, ip := range list {
+		if ip.Address != address {
+			out = append(out, ip)
+		}
+	}
+	return out
+}
+
+// zoneNetwork returns the public or utility network of zone, creating it on first use.
+func (s *Server) zoneNetwork(zone, networkType string) *upcloud.Network {
+	for _, n := range s.networks {
+		if n.Zone == zone && n.Type == networkType {
+			return n
+		}
+	}
+
+	address := "94.237.0.0/16"
+	if networkType == upcloud.NetworkTypeUtility {
+		address = "10.0.0.0/8"
+	}
+	n := &upcloud.Network{
+		IPNetworks: upcloud.IPNetworkSlice{
+			{Address: address, DHCP: upcloud.True, Family: upcloud.IPAddressFamilyIPv4},
+		},
+		Name:    fmt.Sprintf("%s %s", zone, networkType),
+		Type:    networkType,
+		UUID:    newUUID(0x03),
+		Zone:    zone,
+		Servers: upcloud.NetworkServerSlice{},
+	}
+	s.networks[n.UUID] = n
+	return n
+}
+
+// allocateAddress returns address if it is set or the next free address of family in network.
+func (s *Server) allocateAddress(network *upcloud.Network, family, address string) (string, error) {
+	if family == "" {
+		family = upcloud.IPAddressFamilyIPv4
+	}
+	if address != "" {
+		if network.Type != upcloud.NetworkTypePrivate {
+			return "", fmt.Errorf("addresses can be set only on private networks")
+		}
+		if s.addressInUse(network.UUID, address) {
+			return "", fmt.Errorf("address %s is already in use", address)
+		}
+		return address, nil
+	}
+
+	if family == upcloud.IPAddressFamilyIPv6 {
+		if network.Type != upcloud.NetworkTypePublic {
+			return "", fmt.Errorf("IPv6 addresses are only available on the public network")
+		}
+		s.nextAddress++
+		return fmt.Sprintf("2a04:3540:1000:310:%x::1", s.nextAddress), nil
+	}
+
+	for _, ipNetwork := range network.IPNetworks {
+		if ipNetwork.Family != family {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(ipNetwork.Address)
+		if err != nil {
+			return "", err
+		}
+		for ip := nextIP(cidr.IP, 2); cidr.Contains(ip); ip = nextIP(ip, 1) {
+			if candidate := ip.String(); candidate != ipNetwork.Gateway && !s.addressInUse(network.UUID, candidate) {
+				return candidate, nil
+			}
+		}
+		return "", fmt.Errorf("no free addresses left in %s", ipNetwork.Address)
+	}
+	return "", fmt.Errorf("network %s does not have an %s network", network.UUID, family)
+}
+
+func (s *Server) addressInUse(networkUUID, address string) bool {
+	if _, ok := s.ipAddresses[address]; ok {
+		return true
+	}
+	for _, srv := range s.servers {
+		for _, iface := range srv.details.Networking.Interfaces {
+			if iface.Network != networkUUID {
+				continue
+			}
+			for _, ip := range iface.IPAddresses {
+				if ip.Address == address {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func nextIP(ip net.IP, step int) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for ; step > 0; step-- {
+		for i := len(next) - 1; i >= 0; i-- {
+			next[i]++
+			if next[i] != 0 {
+				break
+			}
+		}
+	}
+	return next
+}
+
+func (s *Server) newMAC() string {
+	s.nextMAC++
+	return fmt.Sprintf("ee:1b:db:ca:%02x:%02x", (s.nextMAC>>8)&0xff, s.nextMAC&0xff)
+}
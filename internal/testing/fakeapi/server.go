@@ -0,0 +1,476 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+)
+
+type serverEntry struct {
+	details       upcloud.ServerDetails
+	firewallRules []upcloud.FirewallRule
+	transition    *transition
+}
+
+// flexInt accepts integers encoded either as JSON numbers or as strings.
+type flexInt int
+
+func (i *flexInt) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*i = flexInt(v)
+	return nil
+}
+
+type createServerInterface struct {
+	IPAddresses struct {
+		IPAddress []request.CreateServerIPAddress `json:"ip_address"`
+	} `json:"ip_addresses"`
+	Type              string          `json:"type"`
+	Network           string          `json:"network"`
+	Index             int             `json:"index"`
+	SourceIPFiltering upcloud.Boolean `json:"source_ip_filtering"`
+	Bootable          upcloud.Boolean `json:"bootable"`
+}
+
+type serverRequest struct {
+	Server struct {
+		BootOrder            string              `json:"boot_order"`
+		CoreNumber           flexInt             `json:"core_number"`
+		Firewall             string              `json:"firewall"`
+		Host                 int                 `json:"host"`
+		Hostname             string              `json:"hostname"`
+		Labels               *upcloud.LabelSlice `json:"labels"`
+		MemoryAmount         flexInt             `json:"memory_amount"`
+		Metadata             upcloud.Boolean     `json:"metadata"`
+		NICModel             string              `json:"nic_model"`
+		Plan                 string              `json:"plan"`
+		ServerGroup          string              `json:"server_group"`
+		SimpleBackup         string              `json:"simple_backup"`
+		TimeZone             string              `json:"timezone"`
+		Title                string              `json:"title"`
+		UserData             string              `json:"user_data"`
+		VideoModel           string              `json:"video_model"`
+		RemoteAccessEnabled  upcloud.Boolean     `json:"remote_access_enabled"`
+		RemoteAccessType     string              `json:"remote_access_type"`
+		RemoteAccessPassword string              `json:"remote_access_password"`
+		Zone                 string              `json:"zone"`
+		StorageDevices       struct {
+			StorageDevice []request.CreateServerStorageDevice `json:"storage_device"`
+		} `json:"storage_devices"`
+		Networking *struct {
+			Interfaces struct {
+				Interface []createServerInterface `json:"interface"`
+			} `json:"interfaces"`
+		} `json:"networking"`
+	} `json:"server"`
+}
+
+func (s *Server) registerServerRoutes() {
+	s.handle(http.MethodGet, "/server", s.getServers)
+	s.handle(http.MethodPost, "/server", s.createServer)
+	s.handle(http.MethodGet, "/server/{uuid}", s.getServer)
+	s.handle(http.MethodPut, "/server/{uuid}", s.modifyServer)
+	s.handle(http.MethodDelete, "/server/{uuid}", s.deleteServer)
+	s.handle(http.MethodPost, "/server/{uuid}/start", s.startServer)
+	s.handle(http.MethodPost, "/server/{uuid}/stop", s.stopServer)
+	s.handle(http.MethodPost, "/server/{uuid}/restart", s.restartServer)
+	s.handle(http.MethodPost, "/server/{uuid}/tag/{tags}", s.tagServer)
+	s.handle(http.MethodPost, "/server/{uuid}/untag/{tags}", s.untagServer)
+}
+
+// lookupServer returns the server with the given UUID after applying any pending state transition. If the server does
+// not exist, an error is written to w and nil is returned.
+func (s *Server) lookupServer(w http.ResponseWriter, uuid string) *serverEntry {
+	e, ok := s.servers[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", fmt.Sprintf("The server %s does not exist.", uuid))
+		return nil
+	}
+	if state, done := e.transition.advance(e.details.State); done {
+		e.details.State = state
+		e.transition = nil
+	}
+	return e
+}
+
+func (s *Server) writeServer(w http.ResponseWriter, status int, e *serverEntry) {
+	writeJSON(w, status, map[string]interface{}{"server": encode(e.details)})
+}
+
+func (s *Server) getServers(w http.ResponseWriter, _ *http.Request, _ params) {
+	uuids := make([]string, 0, len(s.servers))
+	for uuid := range s.servers {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	servers := make([]upcloud.Server, 0, len(uuids))
+	for _, uuid := range uuids {
+		servers = append(servers, s.lookupServer(w, uuid).details.Server)
+	}
+	writeJSON(w, http.StatusOK, wrapList("servers", "server", servers))
+}
+
+func (s *Server) getServer(w http.ResponseWriter, _ *http.Request, p params) {
+	if e := s.lookupServer(w, p["uuid"]); e != nil {
+		s.writeServer(w, http.StatusOK, e)
+	}
+}
+
+func (s *Server) createServer(w http.ResponseWriter, r *http.Request, _ params) {
+	var req serverRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.Server
+
+	if opts.Zone == "" || opts.Hostname == "" || opts.Title == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Zone, hostname and title are required.")
+		return
+	}
+
+	d := upcloud.ServerDetails{
+		Server: upcloud.Server{
+			CoreNumber:   int(opts.CoreNumber),
+			Hostname:     opts.Hostname,
+			MemoryAmount: int(opts.MemoryAmount),
+			Plan:         opts.Plan,
+			State:        upcloud.ServerStateMaintenance,
+			Tags:         upcloud.ServerTagSlice{},
+			Title:        opts.Title,
+			UUID:         newUUID(0x00),
+			Zone:         opts.Zone,
+		},
+		BootOrder:            opts.BootOrder,
+		Firewall:             opts.Firewall,
+		Host:                 opts.Host,
+		Metadata:             opts.Metadata,
+		NICModel:             opts.NICModel,
+		ServerGroup:          opts.ServerGroup,
+		SimpleBackup:         opts.SimpleBackup,
+		Timezone:             opts.TimeZone,
+		VideoModel:           opts.VideoModel,
+		RemoteAccessEnabled:  opts.RemoteAccessEnabled,
+		RemoteAccessType:     opts.RemoteAccessType,
+		RemoteAccessPassword: opts.RemoteAccessPassword,
+	}
+	if opts.Labels != nil {
+		d.Labels = *opts.Labels
+	}
+	if err := s.applyServerPlan(&d, opts.Plan, int(opts.CoreNumber), int(opts.MemoryAmount)); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	setServerDefaults(&d)
+
+	for _, device := range opts.StorageDevices.StorageDevice {
+		if err := s.createServerStorageDevice(&d, device); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	}
+
+	if opts.Networking != nil {
+		for _, iface := range opts.Networking.Interfaces.Interface {
+			if _, err := s.addInterface(&d, iface); err != nil {
+				writeBadRequest(w, err)
+				return
+			}
+		}
+	}
+
+	e := &serverEntry{details: d, transition: s.newTransition(upcloud.ServerStateStarted)}
+	s.servers[d.UUID] = e
+	if d.ServerGroup != "" {
+		s.addServerGroupMember(d.ServerGroup, d.UUID)
+		s.placeServer(w, e)
+	}
+	s.writeServer(w, http.StatusAccepted, e)
+}
+
+func setServerDefaults(d *upcloud.ServerDetails) {
+	if d.Host == 0 {
+		d.Host = 1000000000 + rand.Intn(1000000000) //nolint:gosec // host ID does not need to be cryptographically random
+	}
+	if d.Firewall == "" {
+		d.Firewall = "off"
+	}
+	if d.Metadata == upcloud.Empty {
+		d.Metadata = upcloud.False
+	}
+	if d.NICModel == "" {
+		d.NICModel = upcloud.NICModelVirtio
+	}
+	if d.Timezone == "" {
+		d.Timezone = "UTC"
+	}
+	if d.VideoModel == "" {
+		d.VideoModel = upcloud.VideoModelVGA
+	}
+	if d.BootOrder == "" {
+		d.BootOrder = "disk"
+	}
+	if d.SimpleBackup == "" {
+		d.SimpleBackup = "no"
+	}
+	if d.RemoteAccessEnabled == upcloud.Empty {
+		d.RemoteAccessEnabled = upcloud.False
+	}
+	if d.RemoteAccessType == "" {
+		d.RemoteAccessType = upcloud.RemoteAccessTypeVNC
+	}
+	if d.RemoteAccessEnabled == upcloud.True {
+		d.RemoteAccessHost = fmt.Sprintf("%s.vnc.upcloud.com", d.Zone)
+		d.RemoteAccessPort = 3000 + rand.Intn(1000) //nolint:gosec // port does not need to be cryptographically random
+	}
+}
+
+// applyServerPlan sets the plan, core number and memory amount of the server. Plan `custom` or an empty plan uses
+// the given core number and memory amount.
+func (s *Server) applyServerPlan(d *upcloud.ServerDetails, plan string, coreNumber, memoryAmount int) error {
+	if plan == "" || plan == "custom" {
+		if coreNumber == 0 {
+			coreNumber = 1
+		}
+		if memoryAmount == 0 {
+			memoryAmount = 1024
+		}
+		d.Plan, d.CoreNumber, d.MemoryAmount = "custom", coreNumber, memoryAmount
+		return nil
+	}
+	for _, p := range s.plans {
+		if p.Name == plan {
+			d.Plan, d.CoreNumber, d.MemoryAmount = p.Name, p.CoreNumber, p.MemoryAmount
+			return nil
+		}
+	}
+	return fmt.Errorf("plan %s does not exist", plan)
+}
+
+func (s *Server) modifyServer(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	var req serverRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.Server
+	d := &e.details
+
+	planChanged := opts.Plan != "" && opts.Plan != d.Plan
+	sizeChanged := (opts.CoreNumber != 0 && int(opts.CoreNumber) != d.CoreNumber) || (opts.MemoryAmount != 0 && int(opts.MemoryAmount) != d.MemoryAmount)
+	if (planChanged || sizeChanged) && d.State != upcloud.ServerStateStopped {
+		writeError(w, http.StatusConflict, "SERVER_STATE_ILLEGAL", "The server must be stopped to change its plan or size.")
+		return
+	}
+	if planChanged || sizeChanged {
+		plan := opts.Plan
+		if plan == "" {
+			plan = "custom"
+		}
+		coreNumber, memoryAmount := int(opts.CoreNumber), int(opts.MemoryAmount)
+		if coreNumber == 0 {
+			coreNumber = d.CoreNumber
+		}
+		if memoryAmount == 0 {
+			memoryAmount = d.MemoryAmount
+		}
+		if err := s.applyServerPlan(d, plan, coreNumber, memoryAmount); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	}
+
+	setIfNotEmpty(&d.BootOrder, opts.BootOrder)
+	setIfNotEmpty(&d.Firewall, opts.Firewall)
+	setIfNotEmpty(&d.Hostname, opts.Hostname)
+	setIfNotEmpty(&d.NICModel, opts.NICModel)
+	setIfNotEmpty(&d.SimpleBackup, opts.SimpleBackup)
+	setIfNotEmpty(&d.Timezone, opts.TimeZone)
+	setIfNotEmpty(&d.Title, opts.Title)
+	setIfNotEmpty(&d.VideoModel, opts.VideoModel)
+	setIfNotEmpty(&d.RemoteAccessType, opts.RemoteAccessType)
+	setIfNotEmpty(&d.RemoteAccessPassword, opts.RemoteAccessPassword)
+	if opts.Metadata != upcloud.Empty {
+		d.Metadata = opts.Metadata
+	}
+	if opts.RemoteAccessEnabled != upcloud.Empty {
+		d.RemoteAccessEnabled = opts.RemoteAccessEnabled
+		d.RemoteAccessHost, d.RemoteAccessPort = "", 0
+		setServerDefaults(d)
+	}
+	if opts.Labels != nil {
+		d.Labels = *opts.Labels
+	}
+
+	s.writeServer(w, http.StatusAccepted, e)
+}
+
+func setIfNotEmpty(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+func (s *Server) deleteServer(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.details.State != upcloud.ServerStateStopped {
+		writeError(w, http.StatusBadRequest, "SERVER_STATE_ILLEGAL", "The server must be stopped before it can be deleted.")
+		return
+	}
+
+	deleteStorages := r.URL.Query().Get("storages") == "1"
+	for _, device := range e.details.StorageDevices {
+		st, ok := s.storages[device.UUID]
+		if !ok {
+			continue
+		}
+		st.details.ServerUUIDs = removeString(st.details.ServerUUIDs, e.details.UUID)
+		if deleteStorages && device.Type == upcloud.StorageTypeDisk {
+			delete(s.storages, device.UUID)
+		}
+	}
+	for _, iface := range e.details.Networking.Interfaces {
+		s.detachInterface(e.details.UUID, iface)
+	}
+	for _, tag := range s.tags {
+		tag.Servers = removeString(tag.Servers, e.details.UUID)
+	}
+	s.removeServerGroupMember(e.details.UUID)
+
+	delete(s.servers, e.details.UUID)
+	writeNoContent(w)
+}
+
+func (s *Server) startServer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.details.State != upcloud.ServerStateStopped {
+		writeError(w, http.StatusBadRequest, "SERVER_STATE_ILLEGAL", "The server is not stopped.")
+		return
+	}
+	s.placeServer(w, e)
+	e.details.State = upcloud.ServerStateMaintenance
+	e.transition = s.newTransition(upcloud.ServerStateStarted)
+	s.writeServer(w, http.StatusAccepted, e)
+}
+
+func (s *Server) stopServer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.details.State != upcloud.ServerStateStarted {
+		writeError(w, http.StatusBadRequest, "SERVER_STATE_ILLEGAL", "The server is not started.")
+		return
+	}
+	e.details.State = upcloud.ServerStateMaintenance
+	e.transition = s.newTransition(upcloud.ServerStateStopped)
+	s.writeServer(w, http.StatusAccepted, e)
+}
+
+func (s *Server) restartServer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.details.State != upcloud.ServerStateStarted {
+		writeError(w, http.StatusBadRequest, "SERVER_STATE_ILLEGAL", "The server is not started.")
+		return
+	}
+	e.details.State = upcloud.ServerStateMaintenance
+	e.transition = s.newTransition(upcloud.ServerStateStarted)
+	s.writeServer(w, http.StatusAccepted, e)
+}
+
+func (s *Server) tagServer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	names := strings.Split(p["tags"], ",")
+	for _, name := range names {
+		if _, ok := s.tags[name]; !ok {
+			writeError(w, http.StatusNotFound, "TAG_NOT_FOUND", fmt.Sprintf("The tag %s does not exist.", name))
+			return
+		}
+	}
+	for _, name := range names {
+		tag := s.tags[name]
+		if !containsString(tag.Servers, e.details.UUID) {
+			tag.Servers = append(tag.Servers, e.details.UUID)
+		}
+		if !containsString(e.details.Tags, name) {
+			e.details.Tags = append(e.details.Tags, name)
+		}
+	}
+	s.writeServer(w, http.StatusOK, e)
+}
+
+func (s *Server) untagServer(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupServer(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	for _, name := range strings.Split(p["tags"], ",") {
+		e.details.Tags = removeString(e.details.Tags, name)
+		if tag, ok := s.tags[name]; ok {
+			tag.Servers = removeString(tag.Servers, e.details.UUID)
+		}
+	}
+	s.writeServer(w, http.StatusOK, e)
+}
+
+func containsString[T ~[]string](list T, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString[T ~[]string](list T, value string) T {
+	out := make(T, 0, len(list))
+	for _, v := range list {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// rawField reports whether the JSON object in body contains key under the top-level object wrapper.
+func rawField(body []byte, wrapper, key string) (json.RawMessage, bool) {
+	v := map[string]map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, false
+	}
+	raw, ok := v[wrapper][key]
+	return raw, ok
+}
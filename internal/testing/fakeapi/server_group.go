@@ -0,0 +1,210 @@
+package fakeapi
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+func (s *Server) registerServerGroupRoutes() {
+	s.handle(http.MethodGet, "/server-group", s.getServerGroups)
+	s.handle(http.MethodPost, "/server-group", s.createServerGroup)
+	s.handle(http.MethodGet, "/server-group/{uuid}", s.getServerGroup)
+	s.handle(http.MethodPatch, "/server-group/{uuid}", s.modifyServerGroup)
+	s.handle(http.MethodDelete, "/server-group/{uuid}", s.deleteServerGroup)
+}
+
+func (s *Server) lookupServerGroup(w http.ResponseWriter, uuid string) *upcloud.ServerGroup {
+	group, ok := s.serverGroups[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "SERVER_GROUP_NOT_FOUND", fmt.Sprintf("The server group %s does not exist.", uuid))
+		return nil
+	}
+	return group
+}
+
+// writeServerGroup writes the server group with the anti-affinity status of its members. Started members that are on
+// the same host as a previous started member do not meet the anti-affinity policy.
+func (s *Server) writeServerGroup(w http.ResponseWriter, status int, group *upcloud.ServerGroup) {
+	writeJSON(w, status, map[string]interface{}{"server_group": encode(s.serverGroupWithStatus(w, group))})
+}
+
+func (s *Server) serverGroupWithStatus(w http.ResponseWriter, group *upcloud.ServerGroup) upcloud.ServerGroup {
+	g := *group
+	g.AntiAffinityStatus = nil
+	if g.AntiAffinityPolicy == upcloud.ServerGroupAntiAffinityPolicyOff {
+		return g
+	}
+
+	hosts := make(map[int]bool)
+	for _, uuid := range g.Members {
+		e := s.lookupServer(w, uuid)
+		if e.details.State == upcloud.ServerStateStopped {
+			continue
+		}
+		status := upcloud.ServerAntiAffinityStatusMet
+		if hosts[e.details.Host] {
+			status = upcloud.ServerAntiAffinityStatusUnmet
+		}
+		hosts[e.details.Host] = true
+		g.AntiAffinityStatus = append(g.AntiAffinityStatus, upcloud.ServerGroupMemberAntiAffinityStatus{
+			ServerUUID: uuid,
+			Status:     status,
+		})
+	}
+	return g
+}
+
+func (s *Server) getServerGroups(w http.ResponseWriter, _ *http.Request, _ params) {
+	uuids := make([]string, 0, len(s.serverGroups))
+	for uuid := range s.serverGroups {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	groups := make([]upcloud.ServerGroup, 0, len(uuids))
+	for _, uuid := range uuids {
+		groups = append(groups, s.serverGroupWithStatus(w, s.serverGroups[uuid]))
+	}
+	writeJSON(w, http.StatusOK, wrapList("server_groups", "server_group", groups))
+}
+
+func (s *Server) getServerGroup(w http.ResponseWriter, _ *http.Request, p params) {
+	if group := s.lookupServerGroup(w, p["uuid"]); group != nil {
+		s.writeServerGroup(w, http.StatusOK, group)
+	}
+}
+
+func (s *Server) createServerGroup(w http.ResponseWriter, r *http.Request, _ params) {
+	var group upcloud.ServerGroup
+	if err := decodeBody(r, &group); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if group.Title == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Title is required.")
+		return
+	}
+	if !s.serversExist(w, upcloud.TagServerSlice(group.Members)) {
+		return
+	}
+
+	if group.AntiAffinityPolicy == "" {
+		group.AntiAffinityPolicy = upcloud.ServerGroupAntiAffinityPolicyOff
+	}
+	if group.Labels == nil {
+		group.Labels = upcloud.LabelSlice{}
+	}
+	group.UUID = newUUID(0x0b)
+	group.AntiAffinityStatus = nil
+	members := group.Members
+	group.Members = upcloud.ServerUUIDSlice{}
+	s.serverGroups[group.UUID] = &group
+	s.setServerGroupMembers(&group, members)
+	s.writeServerGroup(w, http.StatusCreated, &group)
+}
+
+func (s *Server) modifyServerGroup(w http.ResponseWriter, r *http.Request, p params) {
+	group := s.lookupServerGroup(w, p["uuid"])
+	if group == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req upcloud.ServerGroup
+	if err := req.UnmarshalJSON(body); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !s.serversExist(w, upcloud.TagServerSlice(req.Members)) {
+		return
+	}
+
+	if req.Title != "" {
+		group.Title = req.Title
+	}
+	if req.AntiAffinityPolicy != "" {
+		group.AntiAffinityPolicy = req.AntiAffinityPolicy
+	}
+	if _, ok := rawField(body, "server_group", "labels"); ok {
+		group.Labels = req.Labels
+		if group.Labels == nil {
+			group.Labels = upcloud.LabelSlice{}
+		}
+	}
+	if _, ok := rawField(body, "server_group", "servers"); ok {
+		s.setServerGroupMembers(group, req.Members)
+	}
+	s.writeServerGroup(w, http.StatusOK, group)
+}
+
+func (s *Server) deleteServerGroup(w http.ResponseWriter, _ *http.Request, p params) {
+	group := s.lookupServerGroup(w, p["uuid"])
+	if group == nil {
+		return
+	}
+	s.setServerGroupMembers(group, nil)
+	delete(s.serverGroups, group.UUID)
+	writeNoContent(w)
+}
+
+// setServerGroupMembers replaces the members of group and updates the server group of the affected servers. A server
+// can only be a member of one group, so the servers are removed from their previous groups.
+func (s *Server) setServerGroupMembers(group *upcloud.ServerGroup, members upcloud.ServerUUIDSlice) {
+	for _, uuid := range group.Members {
+		if e, ok := s.servers[uuid]; ok {
+			e.details.ServerGroup = ""
+		}
+	}
+	group.Members = upcloud.ServerUUIDSlice{}
+	for _, uuid := range members {
+		s.addServerGroupMember(group.UUID, uuid)
+	}
+}
+
+func (s *Server) addServerGroupMember(groupUUID, serverUUID string) {
+	s.removeServerGroupMember(serverUUID)
+	group, ok := s.serverGroups[groupUUID]
+	e, exists := s.servers[serverUUID]
+	if !ok || !exists {
+		return
+	}
+	group.Members = append(group.Members, serverUUID)
+	e.details.ServerGroup = groupUUID
+}
+
+func (s *Server) removeServerGroupMember(serverUUID string) {
+	for _, group := range s.serverGroups {
+		group.Members = removeString(group.Members, serverUUID)
+	}
+	if e, ok := s.servers[serverUUID]; ok {
+		e.details.ServerGroup = ""
+	}
+}
+
+// placeServer picks the host of a server that is being started. Members of anti-affinity groups are moved away from
+// the hosts of the other started members, other servers stay on their current host.
+func (s *Server) placeServer(w http.ResponseWriter, e *serverEntry) {
+	group, ok := s.serverGroups[e.details.ServerGroup]
+	if !ok || group.AntiAffinityPolicy == upcloud.ServerGroupAntiAffinityPolicyOff {
+		return
+	}
+
+	hosts := make(map[int]bool)
+	for _, uuid := range group.Members {
+		if member := s.lookupServer(w, uuid); uuid != e.details.UUID && member.details.State != upcloud.ServerStateStopped {
+			hosts[member.details.Host] = true
+		}
+	}
+	for hosts[e.details.Host] {
+		e.details.Host = 1000000000 + rand.Intn(1000000000) //nolint:gosec // host ID does not need to be cryptographically random
+	}
+}
@@ -0,0 +1,647 @@
+package fakeapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+)
+
+type storageEntry struct {
+	details          upcloud.StorageDetails
+	transition       *transition
+	storageImport    *upcloud.StorageImportDetails
+	importTransition *transition
+}
+
+type storageRequest struct {
+	Storage struct {
+		Size       flexInt             `json:"size"`
+		Tier       string              `json:"tier"`
+		Title      string              `json:"title"`
+		Zone       string              `json:"zone"`
+		BackupRule *upcloud.BackupRule `json:"backup_rule"`
+		Labels     *[]upcloud.Label    `json:"labels"`
+	} `json:"storage"`
+}
+
+type storageDeviceRequest struct {
+	StorageDevice struct {
+		Type        string  `json:"type"`
+		Address     string  `json:"address"`
+		StorageUUID string  `json:"storage"`
+		BootDisk    flexInt `json:"boot_disk"`
+	} `json:"storage_device"`
+}
+
+type storageImportRequest struct {
+	StorageImport struct {
+		Source         string `json:"source"`
+		SourceLocation string `json:"source_location"`
+	} `json:"storage_import"`
+}
+
+func (s *Server) registerStorageRoutes() {
+	s.handle(http.MethodGet, "/storage", s.getStorages)
+	s.handle(http.MethodPost, "/storage", s.createStorage)
+	s.handle(http.MethodGet, "/storage/{uuid}", s.getStorage)
+	s.handle(http.MethodGet, "/storage/{access}/{type}", s.getStorages)
+	s.handle(http.MethodPut, "/storage/{uuid}", s.modifyStorage)
+	s.handle(http.MethodDelete, "/storage/{uuid}", s.deleteStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/clone", s.cloneStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/templatize", s.templatizeStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/backup", s.backupStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/restore", s.restoreStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/resize", s.resizeStorage)
+	s.handle(http.MethodPost, "/storage/{uuid}/import", s.createStorageImport)
+	s.handle(http.MethodGet, "/storage/{uuid}/import", s.getStorageImport)
+	s.handle(http.MethodPut, "/storage/{uuid}/import/upload", s.uploadStorageImport)
+	s.handle(http.MethodPost, "/server/{uuid}/storage/attach", s.attachStorage)
+	s.handle(http.MethodPost, "/server/{uuid}/storage/detach", s.detachStorage)
+	s.handle(http.MethodPost, "/server/{uuid}/cdrom/load", s.loadCDROM)
+	s.handle(http.MethodPost, "/server/{uuid}/cdrom/eject", s.ejectCDROM)
+}
+
+// lookupStorage returns the storage with the given UUID after applying any pending state transitions. If the storage
+// does not exist, an error is written to w and nil is returned.
+func (s *Server) lookupStorage(w http.ResponseWriter, uuid string) *storageEntry {
+	e, ok := s.storages[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "STORAGE_NOT_FOUND", fmt.Sprintf("The storage %s does not exist.", uuid))
+		return nil
+	}
+	if state, done := e.transition.advance(e.details.State); done {
+		e.details.State = state
+		e.transition = nil
+	}
+	if e.storageImport != nil {
+		if state, done := e.importTransition.advance(e.storageImport.State); done {
+			e.storageImport.State = state
+			e.storageImport.Completed = time.Now()
+			e.importTransition = nil
+		}
+	}
+	return e
+}
+
+func (s *Server) writeStorage(w http.ResponseWriter, status int, e *storageEntry) {
+	writeJSON(w, status, map[string]interface{}{"storage": encode(e.details)})
+}
+
+func isStorageFilter(value string) bool {
+	switch value {
+	case upcloud.StorageAccessPublic, upcloud.StorageAccessPrivate, "normal", "backup", "cdrom", "template", "favorite":
+		return true
+	}
+	return false
+}
+
+func (s *Server) getStorages(w http.ResponseWriter, _ *http.Request, p params) {
+	filters := []string{p["access"], p["type"]}
+	if uuid := p["uuid"]; uuid != "" {
+		filters = []string{uuid}
+	}
+
+	uuids := make([]string, 0, len(s.storages))
+	for uuid := range s.storages {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	storages := make([]upcloud.Storage, 0, len(uuids))
+	for _, uuid := range uuids {
+		st := s.lookupStorage(w, uuid).details.Storage
+		if matchesStorageFilters(st, filters) {
+			storages = append(storages, st)
+		}
+	}
+	writeJSON(w, http.StatusOK, wrapList("storages", "storage", storages))
+}
+
+func matchesStorageFilters(st upcloud.Storage, filters []string) bool {
+	for _, f := range filters {
+		switch f {
+		case "", "favorite":
+		case upcloud.StorageAccessPublic, upcloud.StorageAccessPrivate:
+			if st.Access != f {
+				return false
+			}
+		case "normal":
+			if st.Type != upcloud.StorageTypeNormal && st.Type != upcloud.StorageTypeDisk {
+				return false
+			}
+		default:
+			if st.Type != f {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Server) getStorage(w http.ResponseWriter, r *http.Request, p params) {
+	if isStorageFilter(p["uuid"]) {
+		s.getStorages(w, r, p)
+		return
+	}
+	if e := s.lookupStorage(w, p["uuid"]); e != nil {
+		s.writeStorage(w, http.StatusOK, e)
+	}
+}
+
+func (s *Server) newStorage(zone, title, tier string, size int) *storageEntry {
+	if tier == "" {
+		tier = upcloud.StorageTierMaxIOPS
+	}
+	e := &storageEntry{
+		details: upcloud.StorageDetails{
+			Storage: upcloud.Storage{
+				Access:  upcloud.StorageAccessPrivate,
+				Size:    size,
+				State:   upcloud.StorageStateMaintenance,
+				Tier:    tier,
+				Title:   title,
+				Type:    upcloud.StorageTypeNormal,
+				UUID:    newUUID(0x01),
+				Zone:    zone,
+				Created: time.Now().UTC(),
+			},
+			BackupUUIDs: upcloud.BackupUUIDSlice{},
+			ServerUUIDs: upcloud.ServerUUIDSlice{},
+		},
+		transition: s.newTransition(upcloud.StorageStateOnline),
+	}
+	s.storages[e.details.UUID] = e
+	return e
+}
+
+func (s *Server) createStorage(w http.ResponseWriter, r *http.Request, _ params) {
+	var req storageRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.Storage
+	if opts.Zone == "" || opts.Size == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Zone and size are required.")
+		return
+	}
+
+	e := s.newStorage(opts.Zone, opts.Title, opts.Tier, int(opts.Size))
+	e.details.BackupRule = opts.BackupRule
+	if opts.Labels != nil {
+		e.details.Labels = *opts.Labels
+	}
+	s.writeStorage(w, http.StatusCreated, e)
+}
+
+func (s *Server) modifyStorage(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	var req storageRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.Storage
+
+	if opts.Size != 0 && int(opts.Size) != e.details.Size {
+		if int(opts.Size) < e.details.Size {
+			writeError(w, http.StatusBadRequest, "STORAGE_SIZE_INVALID", "The size of a storage cannot be decreased.")
+			return
+		}
+		e.details.Size = int(opts.Size)
+	}
+	setIfNotEmpty(&e.details.Title, opts.Title)
+	if opts.BackupRule != nil {
+		e.details.BackupRule = opts.BackupRule
+	}
+	if opts.Labels != nil {
+		e.details.Labels = *opts.Labels
+	}
+
+	// Update the details of the storage on the servers it is attached to
+	for _, uuid := range e.details.ServerUUIDs {
+		if srv, ok := s.servers[uuid]; ok {
+			for i, device := range srv.details.StorageDevices {
+				if device.UUID == e.details.UUID {
+					srv.details.StorageDevices[i].Size = e.details.Size
+					srv.details.StorageDevices[i].Title = e.details.Title
+				}
+			}
+		}
+	}
+
+	s.writeStorage(w, http.StatusAccepted, e)
+}
+
+func (s *Server) deleteStorage(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if len(e.details.ServerUUIDs) > 0 {
+		writeError(w, http.StatusConflict, "STORAGE_ATTACHED", "The storage is attached to a server.")
+		return
+	}
+	if e.details.State != upcloud.StorageStateOnline && e.details.State != upcloud.StorageStateError {
+		writeError(w, http.StatusConflict, "STORAGE_STATE_ILLEGAL", "The storage is not online.")
+		return
+	}
+	delete(s.storages, e.details.UUID)
+	writeNoContent(w)
+}
+
+func (s *Server) cloneStorage(w http.ResponseWriter, r *http.Request, p params) {
+	source := s.lookupStorage(w, p["uuid"])
+	if source == nil {
+		return
+	}
+
+	var req storageRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.Storage
+
+	tier := opts.Tier
+	if tier == "" {
+		tier = source.details.Tier
+	}
+	e := s.newStorage(opts.Zone, opts.Title, tier, source.details.Size)
+	source.details.State = upcloud.StorageStateCloning
+	source.transition = s.newTransition(upcloud.StorageStateOnline)
+	s.writeStorage(w, http.StatusCreated, e)
+}
+
+func (s *Server) templatizeStorage(w http.ResponseWriter, r *http.Request, p params) {
+	source := s.lookupStorage(w, p["uuid"])
+	if source == nil {
+		return
+	}
+
+	var req storageRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	e := s.newStorage(source.details.Zone, req.Storage.Title, source.details.Tier, source.details.Size)
+	e.details.Type = upcloud.StorageTypeTemplate
+	source.details.State = upcloud.StorageStateCloning
+	source.transition = s.newTransition(upcloud.StorageStateOnline)
+	s.writeStorage(w, http.StatusCreated, e)
+}
+
+func (s *Server) backupStorage(w http.ResponseWriter, r *http.Request, p params) {
+	source := s.lookupStorage(w, p["uuid"])
+	if source == nil {
+		return
+	}
+
+	var req storageRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	e := s.newStorage(source.details.Zone, req.Storage.Title, source.details.Tier, source.details.Size)
+	e.details.Type = upcloud.StorageTypeBackup
+	e.details.Origin = source.details.UUID
+	source.details.BackupUUIDs = append(source.details.BackupUUIDs, e.details.UUID)
+	source.details.State = upcloud.StorageStateBackuping
+	source.transition = s.newTransition(upcloud.StorageStateOnline)
+	s.writeStorage(w, http.StatusCreated, e)
+}
+
+func (s *Server) restoreStorage(w http.ResponseWriter, _ *http.Request, p params) {
+	backup := s.lookupStorage(w, p["uuid"])
+	if backup == nil {
+		return
+	}
+	if backup.details.Type != upcloud.StorageTypeBackup {
+		writeError(w, http.StatusBadRequest, "STORAGE_TYPE_ILLEGAL", "The storage is not a backup.")
+		return
+	}
+	if origin, ok := s.storages[backup.details.Origin]; ok {
+		origin.details.State = upcloud.StorageStateMaintenance
+		origin.transition = s.newTransition(upcloud.StorageStateOnline)
+	}
+	writeNoContent(w)
+}
+
+func (s *Server) resizeStorage(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	backup := s.newStorage(e.details.Zone, fmt.Sprintf("Resize backup of %s", e.details.Title), e.details.Tier, e.details.Size)
+	backup.details.Type = upcloud.StorageTypeBackup
+	backup.details.Origin = e.details.UUID
+	backup.details.State = upcloud.StorageStateOnline
+	backup.transition = nil
+	writeJSON(w, http.StatusOK, map[string]interface{}{"resize_backup": encode(upcloud.ResizeStorageFilesystemBackup{
+		Access:  backup.details.Access,
+		Created: backup.details.Created,
+		Origin:  backup.details.Origin,
+		Servers: upcloud.ServerUUIDSlice{},
+		Size:    backup.details.Size,
+		State:   backup.details.State,
+		Title:   backup.details.Title,
+		Type:    backup.details.Type,
+		UUID:    backup.details.UUID,
+		Zone:    backup.details.Zone,
+	})})
+}
+
+func (s *Server) createStorageImport(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+
+	var req storageImportRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.StorageImport
+
+	e.storageImport = &upcloud.StorageImportDetails{
+		Created:        time.Now().UTC(),
+		Source:         opts.Source,
+		SourceLocation: opts.SourceLocation,
+		State:          upcloud.StorageImportStatePending,
+		UUID:           newUUID(0x07),
+	}
+	switch opts.Source {
+	case upcloud.StorageImportSourceHTTPImport:
+		e.importTransition = s.newTransition(upcloud.StorageImportStateCompleted)
+	case upcloud.StorageImportSourceDirectUpload:
+		e.storageImport.State = upcloud.StorageImportStatePrepared
+		e.storageImport.DirectUploadURL = fmt.Sprintf("%s%s/storage/%s/import/upload", s.URL, apiPrefix, e.details.UUID)
+	default:
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("Unknown import source %s.", opts.Source))
+		return
+	}
+	e.details.State = upcloud.StorageStateMaintenance
+	e.transition = nil
+
+	s.writeStorageImport(w, http.StatusCreated, e)
+}
+
+func (s *Server) uploadStorageImport(w http.ResponseWriter, r *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.storageImport == nil || e.storageImport.State != upcloud.StorageImportStatePrepared {
+		writeError(w, http.StatusBadRequest, "STORAGE_IMPORT_STATE_ILLEGAL", "The storage import is not waiting for an upload.")
+		return
+	}
+
+	n, err := io.Copy(io.Discard, r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	e.storageImport.ClientContentLength = int(n)
+	e.storageImport.ClientContentType = r.Header.Get("Content-Type")
+	e.storageImport.ReadBytes = int(n)
+	e.storageImport.WrittenBytes = int(n)
+	e.storageImport.State = upcloud.StorageImportStateImporting
+	e.importTransition = s.newTransition(upcloud.StorageImportStateCompleted)
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) getStorageImport(w http.ResponseWriter, _ *http.Request, p params) {
+	e := s.lookupStorage(w, p["uuid"])
+	if e == nil {
+		return
+	}
+	if e.storageImport == nil {
+		writeError(w, http.StatusNotFound, "STORAGE_IMPORT_NOT_FOUND", "The storage does not have an import.")
+		return
+	}
+	s.writeStorageImport(w, http.StatusOK, e)
+}
+
+func (s *Server) writeStorageImport(w http.ResponseWriter, status int, e *storageEntry) {
+	if e.storageImport.State == upcloud.StorageImportStateCompleted && e.details.State == upcloud.StorageStateMaintenance {
+		e.details.State = upcloud.StorageStateOnline
+	}
+	v := encode(*e.storageImport).(map[string]interface{})
+	if e.storageImport.Completed.IsZero() {
+		v["completed"] = ""
+	}
+	writeJSON(w, status, map[string]interface{}{"storage_import": v})
+}
+
+func (s *Server) attachStorage(w http.ResponseWriter, r *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+
+	var req storageDeviceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	opts := req.StorageDevice
+
+	e := s.lookupStorage(w, opts.StorageUUID)
+	if e == nil {
+		return
+	}
+	if len(e.details.ServerUUIDs) > 0 {
+		writeError(w, http.StatusConflict, "STORAGE_ATTACHED", "The storage is already attached to a server.")
+		return
+	}
+
+	deviceType := opts.Type
+	if deviceType == "" {
+		deviceType = upcloud.StorageTypeDisk
+	}
+	if err := s.attachStorageDevice(&srv.details, e, deviceType, opts.Address, int(opts.BootDisk)); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	s.writeServer(w, http.StatusOK, srv)
+}
+
+func (s *Server) detachStorage(w http.ResponseWriter, r *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+
+	var req storageDeviceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	devices := make(upcloud.ServerStorageDeviceSlice, 0, len(srv.details.StorageDevices))
+	found := false
+	for _, device := range srv.details.StorageDevices {
+		if device.Address == req.StorageDevice.Address {
+			found = true
+			if st, ok := s.storages[device.UUID]; ok {
+				st.details.ServerUUIDs = removeString(st.details.ServerUUIDs, srv.details.UUID)
+			}
+			continue
+		}
+		devices = append(devices, device)
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "STORAGE_DEVICE_NOT_FOUND", fmt.Sprintf("No storage attached at %s.", req.StorageDevice.Address))
+		return
+	}
+	srv.details.StorageDevices = devices
+	s.writeServer(w, http.StatusOK, srv)
+}
+
+func (s *Server) loadCDROM(w http.ResponseWriter, r *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+
+	var req storageDeviceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	e := s.lookupStorage(w, req.StorageDevice.StorageUUID)
+	if e == nil {
+		return
+	}
+	for i, device := range srv.details.StorageDevices {
+		if device.Type == upcloud.StorageTypeCDROM {
+			srv.details.StorageDevices[i].UUID = e.details.UUID
+			srv.details.StorageDevices[i].Title = e.details.Title
+			srv.details.StorageDevices[i].Size = e.details.Size
+			s.writeServer(w, http.StatusOK, srv)
+			return
+		}
+	}
+	if err := s.attachStorageDevice(&srv.details, e, upcloud.StorageTypeCDROM, "", 0); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	s.writeServer(w, http.StatusOK, srv)
+}
+
+func (s *Server) ejectCDROM(w http.ResponseWriter, _ *http.Request, p params) {
+	srv := s.lookupServer(w, p["uuid"])
+	if srv == nil {
+		return
+	}
+	devices := make(upcloud.ServerStorageDeviceSlice, 0, len(srv.details.StorageDevices))
+	for _, device := range srv.details.StorageDevices {
+		if device.Type != upcloud.StorageTypeCDROM {
+			devices = append(devices, device)
+		}
+	}
+	srv.details.StorageDevices = devices
+	s.writeServer(w, http.StatusOK, srv)
+}
+
+// createServerStorageDevice handles a storage device in a create server request.
+func (s *Server) createServerStorageDevice(d *upcloud.ServerDetails, device request.CreateServerStorageDevice) error {
+	deviceType := device.Type
+	if deviceType == "" {
+		deviceType = upcloud.StorageTypeDisk
+	}
+
+	var e *storageEntry
+	switch device.Action {
+	case "clone":
+		source, ok := s.storages[device.Storage]
+		if !ok {
+			return fmt.Errorf("storage %s does not exist", device.Storage)
+		}
+		size := device.Size
+		if size == 0 {
+			size = source.details.Size
+		}
+		e = s.newStorage(d.Zone, device.Title, device.Tier, size)
+	case "create":
+		if device.Size == 0 {
+			return fmt.Errorf("size is required when creating a storage")
+		}
+		e = s.newStorage(d.Zone, device.Title, device.Tier, device.Size)
+	case "attach":
+		var ok bool
+		if e, ok = s.storages[device.Storage]; !ok {
+			return fmt.Errorf("storage %s does not exist", device.Storage)
+		}
+	default:
+		return fmt.Errorf("unknown storage device action %s", device.Action)
+	}
+	e.details.BackupRule = device.BackupRule
+
+	return s.attachStorageDevice(d, e, deviceType, device.Address, 0)
+}
+
+// attachStorageDevice attaches storage e to server d. If address does not include a position, e.g. `virtio`, the
+// next free position on the bus is used.
+func (s *Server) attachStorageDevice(d *upcloud.ServerDetails, e *storageEntry, deviceType, address string, bootDisk int) error {
+	if address == "" {
+		address = "virtio"
+		if deviceType == upcloud.StorageTypeCDROM {
+			address = "ide"
+		}
+	}
+	if !strings.Contains(address, ":") {
+		used := make(map[string]bool)
+		for _, device := range d.StorageDevices {
+			used[device.Address] = true
+		}
+		for i := 0; ; i++ {
+			candidate := fmt.Sprintf("%s:%d", address, i)
+			if deviceType == upcloud.StorageTypeCDROM || strings.HasPrefix(address, "ide") || strings.HasPrefix(address, "scsi") {
+				candidate = fmt.Sprintf("%s:0:%d", address, i)
+			}
+			if !used[candidate] {
+				address = candidate
+				break
+			}
+		}
+	}
+	for _, device := range d.StorageDevices {
+		if device.Address == address {
+			return fmt.Errorf("address %s is already in use", address)
+		}
+	}
+
+	partOfPlan := "no"
+	if len(d.StorageDevices) == 0 && deviceType == upcloud.StorageTypeDisk && d.Plan != "custom" {
+		partOfPlan = "yes"
+	}
+	e.details.PartOfPlan = partOfPlan
+	d.StorageDevices = append(d.StorageDevices, upcloud.ServerStorageDevice{
+		Address:    address,
+		PartOfPlan: partOfPlan,
+		UUID:       e.details.UUID,
+		Size:       e.details.Size,
+		Tier:       e.details.Tier,
+		Title:      e.details.Title,
+		Type:       deviceType,
+		BootDisk:   bootDisk,
+	})
+	if deviceType == upcloud.StorageTypeDisk {
+		e.details.ServerUUIDs = append(e.details.ServerUUIDs, d.UUID)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+func (s *Server) registerTagRoutes() {
+	s.handle(http.MethodGet, "/tag", s.getTags)
+	s.handle(http.MethodPost, "/tag", s.createTag)
+	s.handle(http.MethodPut, "/tag/{name}", s.modifyTag)
+	s.handle(http.MethodDelete, "/tag/{name}", s.deleteTag)
+}
+
+func (s *Server) lookupTag(w http.ResponseWriter, name string) *upcloud.Tag {
+	tag, ok := s.tags[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "TAG_NOT_FOUND", fmt.Sprintf("The tag %s does not exist.", name))
+		return nil
+	}
+	return tag
+}
+
+func (s *Server) getTags(w http.ResponseWriter, _ *http.Request, _ params) {
+	names := make([]string, 0, len(s.tags))
+	for name := range s.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tags := make([]upcloud.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, *s.tags[name])
+	}
+	writeJSON(w, http.StatusOK, wrapList("tags", "tag", tags))
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request, _ params) {
+	var tag upcloud.Tag
+	if err := decodeBody(r, &tag); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if tag.Name == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Name is required.")
+		return
+	}
+	if _, ok := s.tags[tag.Name]; ok {
+		writeError(w, http.StatusConflict, "TAG_EXISTS", fmt.Sprintf("The tag %s already exists.", tag.Name))
+		return
+	}
+	if !s.serversExist(w, tag.Servers) {
+		return
+	}
+
+	if tag.Servers == nil {
+		tag.Servers = upcloud.TagServerSlice{}
+	}
+	s.tags[tag.Name] = &tag
+	s.setTagServers(&tag, tag.Servers)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"tag": encode(tag)})
+}
+
+func (s *Server) modifyTag(w http.ResponseWriter, r *http.Request, p params) {
+	tag := s.lookupTag(w, p["name"])
+	if tag == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	var req upcloud.Tag
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !s.serversExist(w, req.Servers) {
+		return
+	}
+
+	if req.Name != "" && req.Name != tag.Name {
+		if _, ok := s.tags[req.Name]; ok {
+			writeError(w, http.StatusConflict, "TAG_EXISTS", fmt.Sprintf("The tag %s already exists.", req.Name))
+			return
+		}
+		servers := tag.Servers
+		s.setTagServers(tag, nil)
+		delete(s.tags, tag.Name)
+		tag.Name = req.Name
+		s.tags[tag.Name] = tag
+		s.setTagServers(tag, servers)
+	}
+	if _, ok := rawField(body, "tag", "description"); ok {
+		tag.Description = req.Description
+	}
+	if _, ok := rawField(body, "tag", "servers"); ok {
+		s.setTagServers(tag, req.Servers)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tag": encode(*tag)})
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, _ *http.Request, p params) {
+	tag := s.lookupTag(w, p["name"])
+	if tag == nil {
+		return
+	}
+	s.setTagServers(tag, nil)
+	delete(s.tags, tag.Name)
+	writeNoContent(w)
+}
+
+func (s *Server) serversExist(w http.ResponseWriter, uuids upcloud.TagServerSlice) bool {
+	for _, uuid := range uuids {
+		if _, ok := s.servers[uuid]; !ok {
+			writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", fmt.Sprintf("The server %s does not exist.", uuid))
+			return false
+		}
+	}
+	return true
+}
+
+// setTagServers replaces the servers of tag and updates the tag lists of the affected servers.
+func (s *Server) setTagServers(tag *upcloud.Tag, uuids upcloud.TagServerSlice) {
+	for _, e := range s.servers {
+		e.details.Tags = removeString(e.details.Tags, tag.Name)
+	}
+	tag.Servers = upcloud.TagServerSlice{}
+	for _, uuid := range uuids {
+		if e, ok := s.servers[uuid]; ok && !containsString(tag.Servers, uuid) {
+			tag.Servers = append(tag.Servers, uuid)
+			e.details.Tags = append(e.details.Tags, tag.Name)
+		}
+	}
+}
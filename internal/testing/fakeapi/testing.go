@@ -0,0 +1,72 @@
+package fakeapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/stretchr/testify/require"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+)
+
+// Meta returns provider meta that uses the fake API. Resource states are polled without delay, so that state
+// transitions of the fake API are visible on the first poll.
+func (s *Server) Meta() *config.Meta {
+	return &config.Meta{Service: s.Service(), PollInterval: time.Millisecond}
+}
+
+// NewMeta starts a new fake API that is closed when the test finishes and returns provider meta that uses it.
+func NewMeta(t *testing.T) *config.Meta {
+	t.Helper()
+
+	api := New()
+	t.Cleanup(api.Close)
+	return api.Meta()
+}
+
+// CreateServer creates a server with a clone of the Ubuntu Server 22.04 template as its boot disk and a public IPv4
+// network interface. The request can be modified before it is sent with the given functions.
+func CreateServer(t *testing.T, svc *service.Service, hostname string, modify ...func(*request.CreateServerRequest)) *upcloud.ServerDetails {
+	t.Helper()
+
+	r := &request.CreateServerRequest{
+		Zone:     "fi-hel1",
+		Hostname: hostname,
+		Title:    hostname,
+		Plan:     "1xCPU-1GB",
+		StorageDevices: request.CreateServerStorageDeviceSlice{
+			{
+				Action:  request.CreateServerStorageDeviceActionClone,
+				Storage: Ubuntu2204,
+				Title:   hostname + "-disk",
+				Size:    25,
+			},
+		},
+		Networking: &request.CreateServerNetworking{
+			Interfaces: request.CreateServerInterfaceSlice{
+				{
+					Type:        upcloud.NetworkTypePublic,
+					IPAddresses: request.CreateServerIPAddressSlice{{Family: upcloud.IPAddressFamilyIPv4}},
+				},
+			},
+		},
+	}
+	for _, fn := range modify {
+		fn(r)
+	}
+
+	server, err := svc.CreateServer(context.Background(), r)
+	require.NoError(t, err)
+	return server
+}
+
+// WithLabels sets the labels of the server created with CreateServer.
+func WithLabels(labels ...upcloud.Label) func(*request.CreateServerRequest) {
+	return func(r *request.CreateServerRequest) {
+		r.Labels = (*upcloud.LabelSlice)(&labels)
+	}
+}
@@ -0,0 +1,136 @@
+package fakeapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+)
+
+// wrappedSlices lists the slice types that the legacy UpCloud API endpoints wrap into an object, e.g. server tags are
+// returned as `"tags": {"tag": ["a", "b"]}`. The keys mirror the custom unmarshallers of the upcloud package.
+var wrappedSlices = map[reflect.Type]string{
+	reflect.TypeOf(upcloud.BackupUUIDSlice{}):          "backup",
+	reflect.TypeOf(upcloud.IPAddressSlice{}):           "ip_address",
+	reflect.TypeOf(upcloud.IPNetworkSlice{}):           "ip_network",
+	reflect.TypeOf(upcloud.LabelSlice{}):               "label",
+	reflect.TypeOf(upcloud.NetworkServerSlice{}):       "server",
+	reflect.TypeOf(upcloud.RouterNetworkSlice{}):       "network",
+	reflect.TypeOf(upcloud.ServerInterfaceSlice{}):     "interface",
+	reflect.TypeOf(upcloud.ServerStorageDeviceSlice{}): "storage_device",
+	reflect.TypeOf(upcloud.ServerTagSlice{}):           "tag",
+	reflect.TypeOf(upcloud.ServerUUIDSlice{}):          "server",
+	reflect.TypeOf(upcloud.TagServerSlice{}):           "server",
+}
+
+var booleanType = reflect.TypeOf(upcloud.Boolean(0))
+
+// encode converts v into a value that marshals to the JSON format of the legacy UpCloud API endpoints.
+func encode(v interface{}) interface{} {
+	return encodeValue(reflect.ValueOf(v))
+}
+
+// wrapList encodes items as a list wrapped in two objects, e.g. `{"servers": {"server": [...]}}`.
+func wrapList(outer, inner string, items interface{}) map[string]interface{} {
+	v := reflect.ValueOf(items)
+	list := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		list = append(list, encodeValue(v.Index(i)))
+	}
+	return map[string]interface{}{outer: map[string]interface{}{inner: list}}
+}
+
+func encodeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if key, ok := wrappedSlices[v.Type()]; ok {
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, encodeValue(v.Index(i)))
+		}
+		return map[string]interface{}{key: list}
+	}
+
+	if v.Type() == booleanType {
+		if upcloud.Boolean(v.Int()) == upcloud.True {
+			return "yes"
+		}
+		return "no"
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		if _, ok := v.Interface().(fmt.Stringer); ok {
+			// Types such as time.Time implement their own JSON marshalling
+			return v.Interface()
+		}
+		m := make(map[string]interface{})
+		encodeStruct(v, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, encodeValue(v.Index(i)))
+		}
+		return list
+	default:
+		return v.Interface()
+	}
+}
+
+func encodeStruct(v reflect.Value, m map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+
+		if f.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			encodeStruct(fv, m)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if hasOption(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		value := encodeValue(fv)
+		if hasOption(opts, "string") {
+			switch fv.Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+				value = fmt.Sprint(value)
+			}
+		}
+		m[name] = value
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
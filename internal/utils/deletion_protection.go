@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func DeletionProtectionSchema(resource string) *schema.Schema {
+	return &schema.Schema{
+		Description: fmt.Sprintf("If set to `true`, the %[1]s cannot be deleted or replaced. To delete the %[1]s, set this to `false` and apply the change before deleting it.", resource),
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+	}
+}
+
+// CheckDeletionProtection returns an error diagnostic, if deletion_protection is enabled for the resource. Delete
+// functions should call this before deleting the resource.
+func CheckDeletionProtection(d *schema.ResourceData) diag.Diagnostics {
+	if !d.Get("deletion_protection").(bool) {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "deletion protection is enabled",
+		Detail:   fmt.Sprintf("Resource %s cannot be deleted while deletion_protection is enabled. Set deletion_protection to false and apply the change before deleting the resource.", d.Id()),
+	}}
+}
+
+// PreventProtectedReplacement returns a CustomizeDiff function that fails the plan, if a resource with
+// deletion_protection enabled would be replaced because of changes in ForceNew attributes of s. It should be the last
+// function of the sequence, so that changes planned by the other CustomizeDiff functions are included.
+func PreventProtectedReplacement(s map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if d.Id() == "" {
+			return nil
+		}
+
+		var keys []string
+		seen := make(map[string]bool)
+		for _, key := range append(d.GetChangedKeysPrefix(""), d.UpdatedKeys()...) {
+			if !seen[key] && isForceNew(s, strings.Split(key, ".")) {
+				keys = append(keys, key)
+			}
+			seen[key] = true
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		sort.Strings(keys)
+		return CheckReplacementAllowed(d, keys...)
+	}
+}
+
+// CheckReplacementAllowed returns an error, if the resource has deletion_protection enabled. The error lists the keys
+// that require replacing the resource. Use this in CustomizeDiff functions before calling ForceNew.
+func CheckReplacementAllowed(d *schema.ResourceDiff, keys ...string) error {
+	// Use the value from the state, so that protection cannot be disabled in the same apply that replaces the resource
+	protected, _ := d.GetChange("deletion_protection")
+	if p, ok := protected.(bool); !ok || !p {
+		return nil
+	}
+	return fmt.Errorf("changing %s requires replacing the resource, but deletion_protection is enabled. Set deletion_protection to false and apply the change before replacing the resource", strings.Join(keys, ", "))
+}
+
+// isForceNew reports whether changing the attribute at the given address forces a new resource.
+func isForceNew(s map[string]*schema.Schema, addr []string) bool {
+	v, ok := s[addr[0]]
+	if !ok {
+		return false
+	}
+	if v.ForceNew {
+		return true
+	}
+
+	// Nested blocks are addressed as block.index.attribute
+	if r, ok := v.Elem.(*schema.Resource); ok && len(addr) > 2 {
+		return isForceNew(r.Schema, addr[2:])
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDeletionProtectionResource() *schema.Resource {
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"title": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"backup": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"interval": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+			"deletion_protection": DeletionProtectionSchema("test resource"),
+		},
+	}
+	r.CustomizeDiff = PreventProtectedReplacement(r.Schema)
+	return r
+}
+
+func TestPreventProtectedReplacement(t *testing.T) {
+	r := testDeletionProtectionResource()
+	state := &terraform.InstanceState{
+		ID: "test",
+		Attributes: map[string]string{
+			"id":                  "test",
+			"title":               "test",
+			"zone":                "fi-hel1",
+			"backup.#":            "1",
+			"backup.0.interval":   "daily",
+			"deletion_protection": "true",
+		},
+	}
+	diff := func(cfg map[string]interface{}) error {
+		_, err := r.SimpleDiff(context.Background(), state, terraform.NewResourceConfigRaw(cfg), nil)
+		return err
+	}
+	cfg := func(zone, interval string, protected bool) map[string]interface{} {
+		return map[string]interface{}{
+			"title":               "modified",
+			"zone":                zone,
+			"backup":              []interface{}{map[string]interface{}{"interval": interval}},
+			"deletion_protection": protected,
+		}
+	}
+
+	// Changes that do not replace the resource are allowed
+	assert.NoError(t, diff(cfg("fi-hel1", "daily", true)))
+
+	err := diff(cfg("de-fra1", "daily", true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changing zone requires replacing the resource")
+
+	err = diff(cfg("fi-hel1", "weekly", true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changing backup.0.interval requires replacing the resource")
+
+	// Protection must be disabled in a separate apply
+	require.Error(t, diff(cfg("de-fra1", "daily", false)))
+	state.Attributes["deletion_protection"] = "false"
+	assert.NoError(t, diff(cfg("de-fra1", "daily", false)))
+}
+
+func TestCheckDeletionProtection(t *testing.T) {
+	d := testDeletionProtectionResource().TestResourceData()
+	d.SetId("test")
+	assert.Empty(t, CheckDeletionProtection(d))
+
+	require.NoError(t, d.Set("deletion_protection", true))
+	diags := CheckDeletionProtection(d)
+	require.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail, "Set deletion_protection to false")
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var labelSelectorRegexp = regexp.MustCompile(`^\s*([a-zA-Z0-9][a-zA-Z0-9_-]*)\s*(=|!=|\s+in\s+)\s*(.*?)\s*$`)
+
+// LabelSelector is a list of label requirements. Labels match the selector, if they match all of its requirements.
+type LabelSelector []labelRequirement
+
+type labelRequirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+// ParseLabelSelector parses label requirements in `key=value`, `key!=value` or `key in (value1, value2)` format.
+func ParseLabelSelector(requirements []string) (LabelSelector, error) {
+	selector := make(LabelSelector, 0, len(requirements))
+	for _, requirement := range requirements {
+		r, err := parseLabelRequirement(requirement)
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+func parseLabelRequirement(requirement string) (labelRequirement, error) {
+	m := labelSelectorRegexp.FindStringSubmatch(requirement)
+	if m == nil || strings.HasPrefix(m[3], "=") {
+		return labelRequirement{}, fmt.Errorf("invalid label selector %q, expected `key=value`, `key!=value` or `key in (value1, value2)`", requirement)
+	}
+
+	r := labelRequirement{key: m[1], operator: strings.TrimSpace(m[2])}
+	if r.operator != "in" {
+		r.values = []string{m[3]}
+		return r, nil
+	}
+
+	list := m[3]
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return labelRequirement{}, fmt.Errorf("invalid label selector %q, values of `in` operator must be enclosed in parentheses", requirement)
+	}
+	for _, v := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(list, "("), ")"), ",") {
+		r.values = append(r.values, strings.TrimSpace(v))
+	}
+	return r, nil
+}
+
+// Matches reports whether labels match all requirements of the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+	switch r.operator {
+	case "!=":
+		return !ok || value != r.values[0]
+	default:
+		if !ok {
+			return false
+		}
+		for _, v := range r.values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
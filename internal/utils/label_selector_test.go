@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "role": "web"}
+
+	matches := func(requirements ...string) bool {
+		t.Helper()
+		selector, err := ParseLabelSelector(requirements)
+		require.NoError(t, err)
+		return selector.Matches(labels)
+	}
+
+	assert.True(t, matches())
+	assert.True(t, matches("env=prod"))
+	assert.True(t, matches("env = prod", "role!=db"))
+	assert.True(t, matches("owner!=team-a"))
+	assert.True(t, matches("role in (web, api)"))
+	assert.False(t, matches("env=dev"))
+	assert.False(t, matches("env=prod", "role!=web"))
+	assert.False(t, matches("role in (db,cache)"))
+	assert.False(t, matches("owner=team-a"))
+	assert.False(t, matches("owner in (team-a)"))
+
+	for _, invalid := range []string{"env", "env==prod", "=prod", "role in web,api", "role in (web"} {
+		_, err := ParseLabelSelector([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -50,3 +52,75 @@ var ValidateLabelsDiagFunc = validation.AllDiag(
 	validation.MapKeyMatch(regexp.MustCompile("^([a-zA-Z0-9])+([a-zA-Z0-9_-])*$"), ""),
 	validation.MapValueLenBetween(0, 255),
 )
+
+// LabelsAllSchema returns the schema for the computed labels_all field that contains the labels of the resource merged
+// with the default labels of the provider.
+func LabelsAllSchema(resource string) *schema.Schema {
+	return &schema.Schema{
+		Description: fmt.Sprintf("Key-value pairs assigned to the %s, including the default labels of the provider.", resource),
+		Type:        schema.TypeMap,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+		Computed: true,
+	}
+}
+
+// MergeDefaultLabels is a CustomizeDiff function that plans labels_all to contain the default labels of the provider
+// merged with the labels of the resource. Labels of the resource take precedence over the default labels.
+func MergeDefaultLabels(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("labels") {
+		return d.SetNewComputed("labels_all")
+	}
+
+	return d.SetNew("labels_all", mergeLabels(defaultLabels(meta), d.Get("labels").(map[string]interface{})))
+}
+
+// LabelsWithDefaults returns the labels of the resource merged with the default labels of the provider.
+func LabelsWithDefaults(d *schema.ResourceData, meta interface{}) map[string]interface{} {
+	return mergeLabels(defaultLabels(meta), d.Get("labels").(map[string]interface{}))
+}
+
+// SetLabels sets labels_all to the labels of the remote resource and labels to the remote labels excluding the ones
+// that have been inherited from the default labels of the provider. Default labels that are also defined in the
+// resource configuration are kept in labels to avoid perpetual diff.
+func SetLabels(d *schema.ResourceData, meta interface{}, labels map[string]string) error {
+	defaults := defaultLabels(meta)
+	configured := d.Get("labels").(map[string]interface{})
+
+	own := make(map[string]string)
+	for k, v := range labels {
+		if dv, ok := defaults[k]; ok && dv == v {
+			if _, ok := configured[k]; !ok {
+				continue
+			}
+		}
+		own[k] = v
+	}
+
+	if err := d.Set("labels", own); err != nil {
+		return err
+	}
+
+	return d.Set("labels_all", labels)
+}
+
+func defaultLabels(meta interface{}) map[string]string {
+	if m, ok := meta.(*config.Meta); ok && m != nil {
+		return m.DefaultLabels
+	}
+
+	return nil
+}
+
+func mergeLabels(defaults map[string]string, labels map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(labels))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	return merged
+}
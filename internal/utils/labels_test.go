@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLabelsResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"labels":     LabelsSchema("test resource"),
+			"labels_all": LabelsAllSchema("test resource"),
+		},
+		CustomizeDiff: MergeDefaultLabels,
+	}
+}
+
+func TestMergeDefaultLabels(t *testing.T) {
+	meta := &config.Meta{DefaultLabels: map[string]string{"env": "prod", "team": "core"}}
+	r := testLabelsResource()
+
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"labels": map[string]interface{}{"team": "web", "app": "shop"},
+	})
+	diff, err := r.SimpleDiff(context.Background(), nil, cfg, meta)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", diff.Attributes["labels_all.env"].New)
+	assert.Equal(t, "web", diff.Attributes["labels_all.team"].New)
+	assert.Equal(t, "shop", diff.Attributes["labels_all.app"].New)
+
+	state := &terraform.InstanceState{
+		ID: "test",
+		Attributes: map[string]string{
+			"id":              "test",
+			"labels.%":        "2",
+			"labels.team":     "web",
+			"labels.app":      "shop",
+			"labels_all.%":    "3",
+			"labels_all.env":  "prod",
+			"labels_all.team": "web",
+			"labels_all.app":  "shop",
+		},
+	}
+	diff, err = r.SimpleDiff(context.Background(), state, cfg, meta)
+	require.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff: %v", diff)
+
+	meta.DefaultLabels["env"] = "dev"
+	diff, err = r.SimpleDiff(context.Background(), state, cfg, meta)
+	require.NoError(t, err)
+	require.NotNil(t, diff)
+	assert.Equal(t, "dev", diff.Attributes["labels_all.env"].New)
+}
+
+func TestSetLabels(t *testing.T) {
+	meta := &config.Meta{DefaultLabels: map[string]string{"env": "prod", "team": "core", "owner": "ops"}}
+	r := testLabelsResource()
+	d := r.TestResourceData()
+	require.NoError(t, d.Set("labels", map[string]string{"team": "core", "app": "shop"}))
+
+	remote := map[string]string{"env": "prod", "team": "core", "owner": "dev", "app": "shop"}
+	require.NoError(t, SetLabels(d, meta, remote))
+
+	// env is inherited from the defaults, team is configured explicitly and owner differs from the default value
+	assert.Equal(t, map[string]interface{}{"team": "core", "owner": "dev", "app": "shop"}, d.Get("labels"))
+	assert.Equal(t, map[string]interface{}{"env": "prod", "team": "core", "owner": "dev", "app": "shop"}, d.Get("labels_all"))
+}
+
+func TestLabelsWithDefaults(t *testing.T) {
+	r := testLabelsResource()
+	d := r.TestResourceData()
+	require.NoError(t, d.Set("labels", map[string]string{"team": "web"}))
+
+	meta := &config.Meta{DefaultLabels: map[string]string{"env": "prod", "team": "core"}}
+	assert.Equal(t, map[string]interface{}{"env": "prod", "team": "web"}, LabelsWithDefaults(d, meta))
+	assert.Equal(t, map[string]interface{}{"team": "web"}, LabelsWithDefaults(d, nil))
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+)
+
+// IsRetryableError reports whether err is an UpCloud API error returned when the target resource is in a state that
+// does not allow the operation, e.g. when a server is in maintenance state or a storage is busy. API rejects these
+// requests without making any changes, so they can be safely retried once the resource has settled.
+func IsRetryableError(err error) bool {
+	var prob *upcloud.Problem
+	if !errors.As(err, &prob) {
+		return false
+	}
+
+	switch code := prob.ErrorCode(); code {
+	case upcloud.ErrCodeServerStateIllegal, upcloud.ErrCodeStorageStateIllegal:
+		return true
+	default:
+		return strings.HasSuffix(code, "_BUSY")
+	}
+}
+
+// RetryWhileBusy calls fn until it succeeds, it returns an error that is not retryable, or timeout is reached. Calls are
+// retried with increasing delay to give the target resource time to settle. See IsRetryableError for retryable errors.
+// Use RetryWhileServerBusy or RetryWhileStorageBusy, when the operation targets a server or a storage.
+func RetryWhileBusy(ctx context.Context, timeout time.Duration, fn func() error) error {
+	return retry.RetryContext(ctx, timeout, func() *retry.RetryError {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if IsRetryableError(err) {
+			tflog.Debug(ctx, "retrying request rejected due to resource state", map[string]interface{}{"error": err.Error()})
+			return retry.RetryableError(err)
+		}
+		return retry.NonRetryableError(err)
+	})
+}
+
+// RetryWhileServerBusy calls fn until it succeeds, it returns an error that is not retryable, or timeout is reached.
+// After a retryable error, the server is polled until it leaves maintenance state before calling fn again.
+func RetryWhileServerBusy(ctx context.Context, meta interface{}, uuid string, timeout time.Duration, fn func() error) error {
+	return retryAfterSettle(ctx, timeout, fn, func(timeout time.Duration) error {
+		_, err := WaitForServerToSettle(ctx, meta, uuid, timeout)
+		return err
+	})
+}
+
+// RetryWhileStorageBusy calls fn until it succeeds, it returns an error that is not retryable, or timeout is reached.
+// After a retryable error, the storage is polled until it is online before calling fn again.
+func RetryWhileStorageBusy(ctx context.Context, meta interface{}, uuid string, timeout time.Duration, fn func() error) error {
+	return retryAfterSettle(ctx, timeout, fn, func(timeout time.Duration) error {
+		_, err := WaitForStorageState(ctx, meta, uuid, upcloud.StorageStateOnline, timeout)
+		return err
+	})
+}
+
+func retryAfterSettle(ctx context.Context, timeout time.Duration, fn func() error, settle func(time.Duration) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := fn()
+		if err == nil || !IsRetryableError(err) {
+			return err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return err
+		}
+		tflog.Debug(ctx, "waiting for resource to settle before retrying request rejected due to resource state", map[string]interface{}{"error": err.Error()})
+		if settleErr := settle(remaining); settleErr != nil {
+			return errors.Join(err, settleErr)
+		}
+	}
+}
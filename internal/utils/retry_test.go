@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	problem := func(code string) error {
+		return &upcloud.Problem{Type: code, Status: http.StatusConflict}
+	}
+
+	assert.True(t, IsRetryableError(problem(upcloud.ErrCodeServerStateIllegal)))
+	assert.True(t, IsRetryableError(problem(upcloud.ErrCodeStorageStateIllegal)))
+	assert.True(t, IsRetryableError(problem("STORAGE_DEVICE_BUSY")))
+	assert.True(t, IsRetryableError(problem("https://developers.upcloud.com/1.3/errors#ERROR_SERVER_BUSY")))
+	assert.True(t, IsRetryableError(fmt.Errorf("modifying server failed: %w", problem(upcloud.ErrCodeServerStateIllegal))))
+	assert.False(t, IsRetryableError(problem(upcloud.ErrCodeServerNotFound)))
+	assert.False(t, IsRetryableError(errors.New("SERVER_STATE_ILLEGAL")))
+	assert.False(t, IsRetryableError(nil))
+}
+
+func TestRetryWhileBusy(t *testing.T) {
+	calls := 0
+	err := RetryWhileBusy(context.Background(), time.Minute, func() error {
+		calls++
+		if calls < 3 {
+			return &upcloud.Problem{Type: upcloud.ErrCodeServerStateIllegal, Status: http.StatusConflict}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	notFound := &upcloud.Problem{Type: upcloud.ErrCodeServerNotFound, Status: http.StatusNotFound}
+	err = RetryWhileBusy(context.Background(), time.Minute, func() error {
+		calls++
+		return notFound
+	})
+	assert.ErrorIs(t, err, notFound)
+	assert.Equal(t, 1, calls)
+
+	busy := &upcloud.Problem{Type: upcloud.ErrCodeStorageStateIllegal, Status: http.StatusConflict}
+	err = RetryWhileBusy(context.Background(), time.Second, func() error {
+		return busy
+	})
+	assert.ErrorIs(t, err, busy)
+}
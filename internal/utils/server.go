@@ -2,74 +2,174 @@ package utils
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-func VerifyServerStopped(ctx context.Context, stopRequest request.StopServerRequest, meta interface{}) error {
-	if stopRequest.Timeout == 0 {
-		stopRequest.Timeout = time.Minute * 2
-	}
-	if stopRequest.StopType == "" {
-		stopRequest.StopType = upcloud.StopTypeSoft
-	}
+// Defaults for stopping servers, when the resource does not define how the server should be stopped.
+const (
+	DefaultStopType                = upcloud.StopTypeSoft
+	DefaultGracefulShutdownTimeout = 120
+)
 
-	client := meta.(*service.Service)
-	// Get current server state
-	r := &request.GetServerDetailsRequest{
-		UUID: stopRequest.UUID,
-	}
-	server, err := client.GetServerDetails(ctx, r)
-	if err != nil {
-		return err
-	}
-	if server.State != upcloud.ServerStateStopped {
-		// Soft stop with 2 minute timeout, after which hard stop occurs
-		tflog.Info(ctx, "stopping server", map[string]interface{}{"uuid": stopRequest.UUID})
-		_, err := client.StopServer(ctx, &stopRequest)
+// VerifyServerStopped stops the server, if it is not already stopped, and waits at most timeout for it to reach stopped state.
+// The stop request is sent as is, so callers must define the stop type and the timeout of soft stop, e.g. with
+// BuildStopServerRequest.
+func VerifyServerStopped(ctx context.Context, stopRequest request.StopServerRequest, timeout time.Duration, meta interface{}) error {
+	client := meta.(*config.Meta).Service
+	stopped := false
+	err := RetryWhileServerBusy(ctx, meta, stopRequest.UUID, timeout, func() error {
+		// Read the state on every attempt, as a server in maintenance state may settle to stopped state by itself
+		server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: stopRequest.UUID})
 		if err != nil {
 			return err
 		}
-		_, err = client.WaitForServerState(ctx, &request.WaitForServerStateRequest{
-			UUID:         stopRequest.UUID,
-			DesiredState: upcloud.ServerStateStopped,
-			Timeout:      time.Minute * 5,
-		})
+		if server.State == upcloud.ServerStateStopped {
+			stopped = true
+			return nil
+		}
+
+		tflog.Info(ctx, "stopping server", map[string]interface{}{"uuid": stopRequest.UUID, "stop_type": stopRequest.StopType})
+		_, err = client.StopServer(ctx, &stopRequest)
+		return err
+	})
+	if err != nil || stopped {
+		return err
+	}
+	_, err = WaitForServerState(ctx, meta, stopRequest.UUID, upcloud.ServerStateStopped, timeout)
+	return err
+}
+
+// VerifyServerStarted starts the server, if it is not already started, and waits at most timeout for it to reach started state.
+func VerifyServerStarted(ctx context.Context, startRequest request.StartServerRequest, timeout time.Duration, meta interface{}) error {
+	client := meta.(*config.Meta).Service
+	started := false
+	err := RetryWhileServerBusy(ctx, meta, startRequest.UUID, timeout, func() error {
+		// Read the state on every attempt, as a server in maintenance state may settle to started state by itself
+		server, err := client.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: startRequest.UUID})
 		if err != nil {
 			return err
 		}
+		if server.State == upcloud.ServerStateStarted {
+			started = true
+			return nil
+		}
+
+		tflog.Info(ctx, "starting server", map[string]interface{}{"uuid": startRequest.UUID})
+		_, err = client.StartServer(ctx, &startRequest)
+		return err
+	})
+	if err != nil || started {
+		return err
 	}
-	return nil
+	_, err = WaitForServerState(ctx, meta, startRequest.UUID, upcloud.ServerStateStarted, timeout)
+	return err
 }
 
-func VerifyServerStarted(ctx context.Context, startRequest request.StartServerRequest, meta interface{}) error {
-	client := meta.(*service.Service)
-	// Get current server state
-	r := &request.GetServerDetailsRequest{
-		UUID: startRequest.UUID,
+// ServerStopSchema returns the schemas of the stop_type, graceful_shutdown_timeout and allow_stop_for_update arguments
+// for resources that stop the server they are attached to. The changes describe when the server needs to be stopped.
+func ServerStopSchema(changes string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"stop_type": {
+			Description: fmt.Sprintf("The type of stop used when the server needs to be stopped %s. With `soft` stop, the server is asked to shut down gracefully and is forcibly stopped if it has not shut down within `graceful_shutdown_timeout`. With `hard` stop, the server is stopped immediately.", changes),
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     DefaultStopType,
+			ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{
+				upcloud.StopTypeSoft,
+				upcloud.StopTypeHard,
+			}, false)),
+		},
+		"graceful_shutdown_timeout": {
+			Description:      "The time (in seconds) to wait for the server to shut down gracefully before forcibly stopping it, when `stop_type` is `soft`.",
+			Type:             schema.TypeInt,
+			Optional:         true,
+			Default:          DefaultGracefulShutdownTimeout,
+			ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+		},
+		"allow_stop_for_update": {
+			Description: fmt.Sprintf("Allow stopping the server %s. If set to `false`, the operation fails when the server is started.", changes),
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+		},
 	}
-	server, err := client.GetServerDetails(ctx, r)
-	if err != nil {
-		return err
+}
+
+// BuildStopServerRequest returns a request for stopping the server with the stop_type and graceful_shutdown_timeout of
+// the resource. Zero values, e.g. in the state of resources created with earlier provider versions, are replaced with
+// DefaultStopType and DefaultGracefulShutdownTimeout.
+func BuildStopServerRequest(d *schema.ResourceData, uuid string) request.StopServerRequest {
+	r := request.StopServerRequest{
+		UUID:     uuid,
+		StopType: d.Get("stop_type").(string),
+		Timeout:  time.Duration(d.Get("graceful_shutdown_timeout").(int)) * time.Second,
 	}
-	if server.State != upcloud.ServerStateStarted {
-		tflog.Info(ctx, "starting server", map[string]interface{}{"uuid": startRequest.UUID})
-		_, err := client.StartServer(ctx, &startRequest)
-		if err != nil {
-			return err
+	if r.StopType == "" {
+		r.StopType = DefaultStopType
+	}
+	if r.Timeout == 0 {
+		r.Timeout = DefaultGracefulShutdownTimeout * time.Second
+	}
+	return r
+}
+
+// CheckServerStopAllowed returns an error, if the server is started and allow_stop_for_update of the resource is
+// disabled. Resources should call this before stopping the server with VerifyServerStopped.
+func CheckServerStopAllowed(d *schema.ResourceData, server *upcloud.ServerDetails) error {
+	if server.State == upcloud.ServerStateStopped || isServerStopAllowed(d) {
+		return nil
+	}
+	return fmt.Errorf("server %s needs to be stopped, but allow_stop_for_update is disabled. Stop the server or set allow_stop_for_update to true", server.UUID)
+}
+
+func isServerStopAllowed(d *schema.ResourceData) bool {
+	// Configuration is not available when deleting resources, and the state of resources created with earlier provider
+	// versions does not include allow_stop_for_update. Use the default in that case.
+	if d.GetRawConfig().IsNull() {
+		state := d.GetRawState()
+		if !state.IsNull() && state.Type().IsObjectType() && state.Type().HasAttribute("allow_stop_for_update") && state.GetAttr("allow_stop_for_update").IsNull() {
+			return true
 		}
-		_, err = client.WaitForServerState(ctx, &request.WaitForServerStateRequest{
-			UUID:         startRequest.UUID,
-			DesiredState: upcloud.ServerStateStarted,
-			Timeout:      time.Minute * 5,
-		})
-		if err != nil {
-			return err
+	}
+	return d.Get("allow_stop_for_update").(bool)
+}
+
+// LockServers acquires the locks of the given servers so that operations modifying the same server are not run
+// concurrently by different resources. Returned function releases the locks.
+func LockServers(ctx context.Context, meta interface{}, uuids ...string) (unlock func()) {
+	m, ok := meta.(*config.Meta)
+	if !ok || m.ServerLocks == nil {
+		return func() {}
+	}
+
+	// Acquire the locks in a consistent order to avoid deadlocks between resources that lock multiple servers
+	keys := make([]string, 0, len(uuids))
+	seen := make(map[string]bool)
+	for _, uuid := range uuids {
+		if uuid != "" && !seen[uuid] {
+			seen[uuid] = true
+			keys = append(keys, uuid)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		tflog.Debug(ctx, "acquiring server lock", map[string]interface{}{"uuid": key})
+		m.ServerLocks.Lock(key)
+	}
+
+	return func() {
+		for i := len(keys) - 1; i >= 0; i-- {
+			m.ServerLocks.Unlock(keys[i])
 		}
 	}
-	return nil
 }
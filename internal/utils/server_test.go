@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/mutexkv"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockServers(t *testing.T) {
+	ctx := context.Background()
+	meta := &config.Meta{ServerLocks: mutexkv.New()}
+
+	unlock := LockServers(ctx, meta, "b", "a", "b", "")
+
+	locked := make(chan struct{})
+	go func() {
+		defer LockServers(ctx, meta, "a")()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("server lock was acquired twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("server lock was not released")
+	}
+
+	// Locking is no-op when provider meta does not have server locks
+	assert.NotPanics(t, func() {
+		LockServers(ctx, &config.Meta{}, "a")()
+		LockServers(ctx, nil, "a")()
+	})
+}
+
+func TestVerifyServerStopped_maintenance(t *testing.T) {
+	ctx := context.Background()
+	api := fakeapi.New()
+	defer api.Close()
+	api.TransitionDelay = 50 * time.Millisecond
+	meta := api.Meta()
+
+	server := fakeapi.CreateServer(t, meta.Service, "verify-stopped")
+	_, err := WaitForServerState(ctx, meta, server.UUID, upcloud.ServerStateStarted, time.Second)
+	require.NoError(t, err)
+
+	// Server is in maintenance state while stopping, so it settles to stopped state without another stop request
+	_, err = meta.Service.StopServer(ctx, &request.StopServerRequest{UUID: server.UUID})
+	require.NoError(t, err)
+
+	err = VerifyServerStopped(ctx, request.StopServerRequest{UUID: server.UUID, StopType: upcloud.StopTypeHard}, time.Second, meta)
+	require.NoError(t, err)
+
+	server, err = meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStopped, server.State)
+}
+
+func TestVerifyServerStarted_maintenance(t *testing.T) {
+	ctx := context.Background()
+	api := fakeapi.New()
+	defer api.Close()
+	api.TransitionDelay = 50 * time.Millisecond
+	meta := api.Meta()
+
+	// New server is in maintenance state until it has started
+	server := fakeapi.CreateServer(t, meta.Service, "verify-started")
+	require.Equal(t, upcloud.ServerStateMaintenance, server.State)
+
+	err := VerifyServerStarted(ctx, request.StartServerRequest{UUID: server.UUID}, time.Second, meta)
+	require.NoError(t, err)
+
+	server, err = meta.Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: server.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, upcloud.ServerStateStarted, server.State)
+}
+
+func TestBuildStopServerRequest(t *testing.T) {
+	s := ServerStopSchema("to test")
+
+	d := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"stop_type":                 upcloud.StopTypeHard,
+		"graceful_shutdown_timeout": 30,
+	})
+	assert.Equal(t, request.StopServerRequest{
+		UUID:     "server",
+		StopType: upcloud.StopTypeHard,
+		Timeout:  30 * time.Second,
+	}, BuildStopServerRequest(d, "server"))
+
+	// State of resources created with earlier provider versions does not include the stop arguments
+	d, err := schema.InternalMap(s).Data(&terraform.InstanceState{ID: "storage"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, request.StopServerRequest{
+		UUID:     "server",
+		StopType: DefaultStopType,
+		Timeout:  DefaultGracefulShutdownTimeout * time.Second,
+	}, BuildStopServerRequest(d, "server"))
+}
+
+func TestCheckServerStopAllowed(t *testing.T) {
+	s := ServerStopSchema("to test")
+	started := &upcloud.ServerDetails{Server: upcloud.Server{UUID: "server", State: upcloud.ServerStateStarted}}
+	stopped := &upcloud.ServerDetails{Server: upcloud.Server{UUID: "server", State: upcloud.ServerStateStopped}}
+
+	d := schema.TestResourceDataRaw(t, s, map[string]interface{}{})
+	assert.NoError(t, CheckServerStopAllowed(d, started))
+
+	d = schema.TestResourceDataRaw(t, s, map[string]interface{}{"allow_stop_for_update": false})
+	assert.NoError(t, CheckServerStopAllowed(d, stopped))
+	err := CheckServerStopAllowed(d, started)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allow_stop_for_update is disabled")
+
+	// When deleting resources created with earlier provider versions, the state does not include allow_stop_for_update
+	d, err = schema.InternalMap(s).Data(&terraform.InstanceState{
+		ID:       "storage",
+		RawState: cty.ObjectVal(map[string]cty.Value{"allow_stop_for_update": cty.NullVal(cty.Bool)}),
+	}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, CheckServerStopAllowed(d, started))
+}
@@ -57,6 +57,31 @@ func FilterNetworks(vs []upcloud.Network, fns ...func(upcloud.Network) (bool, er
 	return vsf, nil
 }
 
+func FilterServers(vs []upcloud.Server, fns ...func(upcloud.Server) (bool, error)) ([]upcloud.Server, error) {
+	vsf := []upcloud.Server{}
+
+	for _, v := range vs {
+		matched := true
+		for _, fn := range fns {
+			m, err := fn(v)
+			if err != nil {
+				return nil, err
+			}
+
+			if !m {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			vsf = append(vsf, v)
+		}
+	}
+
+	return vsf, nil
+}
+
 // WithRetry attempts to call the provided function until it has been successfully called or the number of calls exceeds retries delaying the consecutive calls by given delay
 func WithRetry(fn func() (interface{}, error), retries int, delay time.Duration) (interface{}, error) {
 	var err error
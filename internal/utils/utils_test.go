@@ -63,6 +63,27 @@ func TestFilterNetworks(t *testing.T) {
 	}
 }
 
+func TestFilterServers(t *testing.T) {
+	toFilter := []upcloud.Server{
+		{Hostname: "web-1", State: upcloud.ServerStateStarted},
+		{Hostname: "web-2", State: upcloud.ServerStateStopped},
+		{Hostname: "db-1", State: upcloud.ServerStateStarted},
+	}
+
+	filtered, err := FilterServers(toFilter, func(s upcloud.Server) (bool, error) {
+		return regexp.MatchString("^web-", s.Hostname)
+	}, func(s upcloud.Server) (bool, error) {
+		return s.State == upcloud.ServerStateStarted, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []upcloud.Server{toFilter[0]}, filtered)
+
+	_, err = FilterServers(toFilter, func(s upcloud.Server) (bool, error) {
+		return regexp.MatchString("(", s.Hostname)
+	})
+	assert.Error(t, err)
+}
+
 func TestWithRetry(t *testing.T) {
 	fail := func() (interface{}, error) {
 		return nil, fmt.Errorf("")
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+)
+
+const defaultPollInterval = time.Second * 5
+
+// WaitForServerState waits at most timeout for the server to reach the desired state.
+func WaitForServerState(ctx context.Context, meta interface{}, uuid, desiredState string, timeout time.Duration) (*upcloud.ServerDetails, error) {
+	var server *upcloud.ServerDetails
+	err := pollState(ctx, meta, timeout, func() (done bool, err error) {
+		server, err = meta.(*config.Meta).Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+		return err == nil && server.State == desiredState, err
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout reached while waiting for server %s to enter state %q", uuid, desiredState)
+	}
+	return server, err
+}
+
+// WaitForServerToSettle waits at most timeout for the server to leave maintenance state.
+func WaitForServerToSettle(ctx context.Context, meta interface{}, uuid string, timeout time.Duration) (*upcloud.ServerDetails, error) {
+	var server *upcloud.ServerDetails
+	err := pollState(ctx, meta, timeout, func() (done bool, err error) {
+		server, err = meta.(*config.Meta).Service.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+		return err == nil && server.State != upcloud.ServerStateMaintenance, err
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout reached while waiting for server %s to leave state %q", uuid, upcloud.ServerStateMaintenance)
+	}
+	return server, err
+}
+
+// WaitForStorageState waits at most timeout for the storage to reach the desired state.
+func WaitForStorageState(ctx context.Context, meta interface{}, uuid, desiredState string, timeout time.Duration) (*upcloud.StorageDetails, error) {
+	var storage *upcloud.StorageDetails
+	err := pollState(ctx, meta, timeout, func() (done bool, err error) {
+		storage, err = meta.(*config.Meta).Service.GetStorageDetails(ctx, &request.GetStorageDetailsRequest{UUID: uuid})
+		return err == nil && storage.State == desiredState, err
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout reached while waiting for storage %s to enter state %q", uuid, desiredState)
+	}
+	return storage, err
+}
+
+// pollState calls poll every poll interval of the provider meta until it is done, it returns an error, or timeout is
+// reached. The first call is made after one interval, as resources may not enter a transitional state immediately
+// after the operation that started the transition.
+func pollState(ctx context.Context, meta interface{}, timeout time.Duration, poll func() (bool, error)) error {
+	interval := defaultPollInterval
+	if m, ok := meta.(*config.Meta); ok && m.PollInterval > 0 {
+		interval = m.PollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		done, err := poll()
+		if err != nil || done {
+			return err
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// SetDefaultZone is a CustomizeDiff function that plans the zone of the resource to be the default zone of the
+// provider when zone is not set in the resource configuration. Changes in the default zone are handled the same way as
+// changes in the zone of the resource, e.g. resources with ForceNew zone are replaced.
+func SetDefaultZone(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if zoneConfigured(d) {
+		return nil
+	}
+
+	zone := defaultZone(meta)
+	if zone == "" {
+		return fmt.Errorf("zone must be set either in the resource configuration or with the provider zone argument")
+	}
+
+	if d.Get("zone").(string) == zone {
+		return nil
+	}
+
+	return d.SetNew("zone", zone)
+}
+
+func zoneConfigured(d *schema.ResourceDiff) bool {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() {
+		// Configuration is not available, so leave zone as it is
+		return true
+	}
+
+	return !raw.GetAttr("zone").IsNull()
+}
+
+func defaultZone(meta interface{}) string {
+	if m, ok := meta.(*config.Meta); ok && m != nil {
+		return m.DefaultZone
+	}
+
+	return ""
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testZoneResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+		},
+		CustomizeDiff: SetDefaultZone,
+	}
+}
+
+func testZoneDiff(t *testing.T, defaultZone, stateZone string, configZone cty.Value) (*terraform.InstanceDiff, error) {
+	t.Helper()
+
+	raw := map[string]interface{}{}
+	if !configZone.IsNull() {
+		raw["zone"] = configZone.AsString()
+	}
+
+	state := &terraform.InstanceState{
+		RawConfig:  cty.ObjectVal(map[string]cty.Value{"zone": configZone}),
+		Attributes: map[string]string{},
+	}
+	if stateZone != "" {
+		state.ID = "test"
+		state.Attributes["id"] = "test"
+		state.Attributes["zone"] = stateZone
+	}
+
+	return testZoneResource().SimpleDiff(context.Background(), state, terraform.NewResourceConfigRaw(raw), &config.Meta{DefaultZone: defaultZone})
+}
+
+func TestSetDefaultZone(t *testing.T) {
+	t.Run("new resource uses default zone", func(t *testing.T) {
+		diff, err := testZoneDiff(t, "de-fra1", "", cty.NullVal(cty.String))
+		require.NoError(t, err)
+		require.NotNil(t, diff)
+		assert.Equal(t, "de-fra1", diff.Attributes["zone"].New)
+	})
+
+	t.Run("configured zone takes precedence", func(t *testing.T) {
+		diff, err := testZoneDiff(t, "de-fra1", "", cty.StringVal("fi-hel1"))
+		require.NoError(t, err)
+		require.NotNil(t, diff)
+		assert.Equal(t, "fi-hel1", diff.Attributes["zone"].New)
+	})
+
+	t.Run("unchanged default zone", func(t *testing.T) {
+		diff, err := testZoneDiff(t, "de-fra1", "de-fra1", cty.NullVal(cty.String))
+		require.NoError(t, err)
+		assert.True(t, diff == nil || diff.Empty(), "unexpected diff: %v", diff)
+	})
+
+	t.Run("changed default zone forces replacement", func(t *testing.T) {
+		diff, err := testZoneDiff(t, "fi-hel1", "de-fra1", cty.NullVal(cty.String))
+		require.NoError(t, err)
+		require.NotNil(t, diff)
+		assert.Equal(t, "fi-hel1", diff.Attributes["zone"].New)
+		assert.True(t, diff.RequiresNew())
+	})
+
+	t.Run("zone is required without default zone", func(t *testing.T) {
+		_, err := testZoneDiff(t, "", "", cty.NullVal(cty.String))
+		assert.Error(t, err)
+	})
+}
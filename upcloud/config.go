@@ -16,11 +16,17 @@ import (
 type Config struct {
 	Username string
 	Password string
+	Token    string
 }
 
 func (c *Config) Client() (*service.Service, error) {
-	client := client.New(c.Username, c.Password)
-	svc := service.New(client)
+	var opts []client.ConfigFn
+	if c.Token != "" {
+		httpClient := client.NewDefaultHTTPClient()
+		withToken(httpClient, c.Token)
+		opts = append(opts, client.WithHTTPClient(httpClient))
+	}
+	svc := service.New(client.New(c.Username, c.Password, opts...))
 	res, err := c.checkLogin(svc)
 	if err != nil {
 		return nil, err
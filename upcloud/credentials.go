@@ -0,0 +1,116 @@
+package upcloud
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultCredentialsProfile = "default"
+
+// credentialsFile is the format of the shared credentials file, e.g.
+//
+//	profiles:
+//	  default:
+//	    username: user
+//	    password: pass
+//	  production:
+//	    token: ucat_...
+type credentialsFile struct {
+	Profiles map[string]credentialsProfile `yaml:"profiles"`
+}
+
+type credentialsProfile struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+}
+
+func defaultCredentialsFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "upcloud", "credentials.yaml")
+}
+
+func readCredentialsFile(path string) (*credentialsFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f credentialsFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse credentials file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// loadProfile fills in the credentials that are not set in the provider configuration from a profile of the shared
+// credentials file. If profile is empty, the default profile is used when the file has one and no credentials are
+// configured otherwise.
+func (c *Config) loadProfile(path, profile string) error {
+	required := profile != ""
+	if !required {
+		if c.Token != "" || c.Username != "" || c.Password != "" {
+			return nil
+		}
+		profile = defaultCredentialsProfile
+	}
+	if path == "" {
+		path = defaultCredentialsFilePath()
+	}
+
+	f, err := readCredentialsFile(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("unable to read credentials profile %s: %w", profile, err)
+	}
+	p, ok := f.Profiles[profile]
+	if !ok {
+		if !required {
+			return nil
+		}
+		return fmt.Errorf("credentials profile %s not found in %s", profile, path)
+	}
+
+	if c.Username == "" {
+		c.Username = p.Username
+	}
+	if c.Password == "" {
+		c.Password = p.Password
+	}
+	if c.Token == "" {
+		c.Token = p.Token
+	}
+	return nil
+}
+
+// tokenTransport authenticates requests with an API token instead of the basic auth credentials added by the UpCloud
+// API client.
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
+
+// withToken configures httpClient to authenticate requests with token.
+func withToken(httpClient *http.Client, token string) {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = &tokenTransport{token: token, base: base}
+}
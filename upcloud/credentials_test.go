@@ -0,0 +1,117 @@
+package upcloud
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCredentialsFile = `
+profiles:
+  default:
+    username: default-user
+    password: default-pass
+  token:
+    token: ucat_token
+`
+
+func writeTestCredentialsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigLoadProfile(t *testing.T) {
+	path := writeTestCredentialsFile(t, testCredentialsFile)
+
+	for _, tc := range []struct {
+		name    string
+		config  Config
+		path    string
+		profile string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "default profile",
+			path: path,
+			want: Config{Username: "default-user", Password: "default-pass"},
+		},
+		{
+			name:    "named profile",
+			path:    path,
+			profile: "token",
+			want:    Config{Token: "ucat_token"},
+		},
+		{
+			name:   "explicit credentials skip default profile",
+			config: Config{Username: "user", Password: "pass"},
+			path:   path,
+			want:   Config{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "explicit credentials take precedence over named profile",
+			config:  Config{Password: "pass"},
+			path:    path,
+			profile: "default",
+			want:    Config{Username: "default-user", Password: "pass"},
+		},
+		{
+			name:    "unknown profile",
+			path:    path,
+			profile: "unknown",
+			wantErr: true,
+		},
+		{
+			name: "missing file without profile",
+			path: filepath.Join(t.TempDir(), "missing.yaml"),
+			want: Config{},
+		},
+		{
+			name:    "missing file with profile",
+			path:    filepath.Join(t.TempDir(), "missing.yaml"),
+			profile: "default",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			err := config.loadProfile(tc.path, tc.profile)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, config)
+		})
+	}
+}
+
+func TestConfigLoadProfile_invalidFile(t *testing.T) {
+	path := writeTestCredentialsFile(t, "profiles: [")
+	config := Config{}
+	assert.Error(t, config.loadProfile(path, ""))
+}
+
+func TestProviderConfigure_tokenProfile(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	path := writeTestCredentialsFile(t, "profiles:\n  fake:\n    token: "+fakeapi.Token+"\n")
+	diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"api_base_url":     api.URL,
+		"credentials_file": path,
+		"profile":          "fake",
+	}))
+	if diags.HasError() {
+		t.Fatalf("configure failed: %+v", diags)
+	}
+}
@@ -0,0 +1,196 @@
+package upcloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// apiLogSubsystem is the tflog subsystem for logging UpCloud API traffic.
+	apiLogSubsystem = "api"
+	// apiLogEnvVar enables logging of UpCloud API traffic, e.g. TF_LOG_PROVIDER_UPCLOUD_API=DEBUG.
+	apiLogEnvVar = "TF_LOG_PROVIDER_UPCLOUD_API"
+
+	redactedValue = "***"
+)
+
+// sensitiveFields are substrings of the JSON field names whose values are masked in the logged request and response
+// bodies, e.g. password matches also remote_access_password and service_password.
+var sensitiveFields = []string{
+	"password",
+	"private_key",
+	"secret",
+	"token",
+}
+
+// requestIDHeaders are the response headers that may contain an identifier of the request.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+// loggingTransport logs requests sent to the UpCloud API and their responses to the api subsystem of the provider
+// logger. Sensitive values are masked from the logged headers and bodies.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(r.Context(), apiLogSubsystem, tflog.WithLevelFromEnv(apiLogEnvVar))
+
+	fields := map[string]interface{}{
+		"method":          r.Method,
+		"path":            r.URL.Path,
+		"request_headers": redactHeaders(r.Header),
+	}
+	if body, ok := readJSONBody(r.Header, r.GetBody); ok {
+		fields["request_body"] = redactJSON(body)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(r)
+	fields["duration"] = time.Since(start).String()
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.SubsystemDebug(ctx, apiLogSubsystem, "UpCloud API request failed", fields)
+		return resp, err
+	}
+
+	fields["status"] = resp.StatusCode
+	for _, h := range requestIDHeaders {
+		if id := resp.Header.Get(h); id != "" {
+			fields["request_id"] = id
+			break
+		}
+	}
+
+	if isJSON(resp.Header) && resp.Body != nil {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return resp, err
+		}
+		fields["response_body"] = redactJSON(body)
+	}
+
+	tflog.SubsystemDebug(ctx, apiLogSubsystem, "UpCloud API request", fields)
+	return resp, nil
+}
+
+// withHTTPLogging configures httpClient to log UpCloud API traffic.
+func withHTTPLogging(httpClient *http.Client) {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = &loggingTransport{base: base}
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k := range h {
+		if strings.EqualFold(k, "Authorization") {
+			headers[k] = redactedValue
+			continue
+		}
+		headers[k] = h.Get(k)
+	}
+	return headers
+}
+
+// readJSONBody returns a copy of the request body, if it is JSON encoded.
+func readJSONBody(h http.Header, getBody func() (io.ReadCloser, error)) ([]byte, bool) {
+	if !isJSON(h) || getBody == nil {
+		return nil, false
+	}
+
+	body, err := getBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func isJSON(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// redactJSON returns body with the values of sensitive fields masked. Bodies that are not valid JSON are omitted.
+func redactJSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "<invalid JSON body omitted>"
+	}
+
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "<invalid JSON body omitted>"
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if isSensitiveField(k) {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = redactValue(val)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactValue(val)
+		}
+		return v
+	case string:
+		return redactURI(v)
+	default:
+		return v
+	}
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range sensitiveFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURI strips the userinfo from URIs, such as database service URIs, that may contain credentials.
+func redactURI(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = nil
+	return u.String()
+}
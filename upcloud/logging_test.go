@@ -0,0 +1,79 @@
+package upcloud
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	t.Setenv(apiLogEnvVar, "DEBUG")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"user":{"username":"test","password":"hunter2"}}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = w.Write([]byte(`{"users":[{"secret_access_key":"s3cr3t","service_password":"pw","private_key":"key","name":"test"}]}`))
+	}))
+	defer srv.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	c := &http.Client{}
+	withHTTPLogging(c)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/1.3/account", bytes.NewBufferString(`{"user":{"username":"test","password":"hunter2"}}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth("test", "hunter2")
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Response body is still readable after logging
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "s3cr3t")
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, "UpCloud API request", entry["@message"])
+	assert.Equal(t, "POST", entry["method"])
+	assert.Equal(t, "/1.3/account", entry["path"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.NotEmpty(t, entry["duration"])
+	assert.JSONEq(t, `{"user":{"username":"test","password":"***"}}`, entry["request_body"].(string))
+	assert.JSONEq(t, `{"users":[{"secret_access_key":"***","service_password":"***","private_key":"***","name":"test"}]}`, entry["response_body"].(string))
+	assert.Equal(t, "***", entry["request_headers"].(map[string]interface{})["Authorization"])
+
+	assert.NotContains(t, output.String(), "hunter2")
+	assert.NotContains(t, output.String(), "s3cr3t")
+}
+
+func TestRedactJSON(t *testing.T) {
+	assert.Equal(t, "", redactJSON(nil))
+	assert.Equal(t, "<invalid JSON body omitted>", redactJSON([]byte("password=hunter2")))
+	assert.JSONEq(t, `[{"Password":"***","nested":{"token":"***","title":"x"}}]`, redactJSON([]byte(`[{"Password":"a","nested":{"token":"b","title":"x"}}]`)))
+	assert.JSONEq(t, `{"object_storage":{"access_key":"ak","secret_key":"***"}}`, redactJSON([]byte(`{"object_storage":{"access_key":"ak","secret_key":"sk"}}`)))
+	assert.JSONEq(t, `{"server":{"remote_access_password":"***","remote_access_type":"vnc"}}`, redactJSON([]byte(`{"server":{"remote_access_password":"pw","remote_access_type":"vnc"}}`)))
+	assert.JSONEq(t, `{"ssh":{"host_private_key":"***","api_token_id":"***"}}`, redactJSON([]byte(`{"ssh":{"host_private_key":"k","api_token_id":"t"}}`)))
+	assert.JSONEq(t,
+		`{"service_uri":"postgres://db.example.com:11550/defaultdb?sslmode=require","components":[{"route":"https://example.com/path"}],"title":"not://a uri%"}`,
+		redactJSON([]byte(`{"service_uri":"postgres://upadmin:pw@db.example.com:11550/defaultdb?sslmode=require","components":[{"route":"https://user:pw@example.com/path"}],"title":"not://a uri%"}`)),
+	)
+}
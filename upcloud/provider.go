@@ -2,17 +2,23 @@ package upcloud
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/client"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/mutexkv"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/cloud"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/database"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/firewall"
@@ -28,6 +34,7 @@ import (
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/servergroup"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/storage"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/tag"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/utils"
 
 	retryablehttp "github.com/hashicorp/go-retryablehttp"
 )
@@ -47,6 +54,25 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_PASSWORD", nil),
 				Description: "Password for UpCloud API user. Can also be configured using the `UPCLOUD_PASSWORD` environment variable.",
 			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_TOKEN", nil),
+				Description: "API token for UpCloud API. Takes precedence over `username` and `password`. Can also be configured using the `UPCLOUD_TOKEN` environment variable.",
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_PROFILE", nil),
+				Description: "Name of the credentials profile to use from the shared credentials file. Credentials set with provider arguments or environment variables take precedence over the profile. If not set, the `default` profile is used when no other credentials are configured. Can also be configured using the `UPCLOUD_PROFILE` environment variable.",
+			},
+			"credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_CREDENTIALS_FILE", nil),
+				Description: "Path to the shared credentials file. Defaults to `~/.config/upcloud/credentials.yaml`. Can also be configured using the `UPCLOUD_CREDENTIALS_FILE` environment variable.",
+			},
 			"retry_wait_min_sec": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -71,11 +97,55 @@ func Provider() *schema.Provider {
 				Default:     120,
 				Description: "The duration (in seconds) that the provider waits for a HTTP request to towards UpCloud API to complete. Defaults to 120 seconds",
 			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Maximum number of requests per second that the provider sends to the UpCloud API. Defaults to 0, which disables the rate limit",
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of requests to the UpCloud API that the provider has in flight at a time. Defaults to 0, which disables the limit",
+			},
+			"api_base_url": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("UPCLOUD_API_BASE_URL", nil),
+				Description:      "Base URL of the UpCloud API, e.g. `https://api.upcloud.com`. Use this to route API requests through a proxy or to a stand-in API. Can also be configured using the `UPCLOUD_API_BASE_URL` environment variable.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
+			},
+			"ca_certificate_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_CA_CERTIFICATE_FILE", nil),
+				Description: "Path to a PEM encoded file of CA certificates that are trusted in addition to the system certificate pool when connecting to the UpCloud API. Can also be configured using the `UPCLOUD_CA_CERTIFICATE_FILE` environment variable.",
+			},
+			"zone": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UPCLOUD_ZONE", nil),
+				Description: "Default zone for resources that do not define a zone, e.g. `de-fra1`. Changing the default zone replaces the resources that use it, unless the resource can be moved to another zone. Can also be configured using the `UPCLOUD_ZONE` environment variable.",
+			},
+			"default_labels": {
+				Type: schema.TypeMap,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:         true,
+				ValidateDiagFunc: utils.ValidateLabelsDiagFunc,
+				Description:      "Labels that are added to every resource that supports labels. Labels defined in a resource take precedence over the default labels with the same key. The merged labels are available in the `labels_all` attribute of the resource.",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
 			"upcloud_server":                                  server.ResourceServer(),
 			"upcloud_server_group":                            servergroup.ResourceServerGroup(),
+			"upcloud_server_network_interface":                server.ResourceServerNetworkInterface(),
+			"upcloud_server_storage_attachment":               server.ResourceServerStorageAttachment(),
 			"upcloud_router":                                  router.ResourceRouter(),
 			"upcloud_storage":                                 storage.ResourceStorage(),
 			"upcloud_firewall_rules":                          firewall.ResourceFirewallRules(),
@@ -111,6 +181,9 @@ func Provider() *schema.Provider {
 			"upcloud_zones":              cloud.DataSourceZones(),
 			"upcloud_networks":           network.DataSourceNetworks(),
 			"upcloud_hosts":              cloud.DataSourceHosts(),
+			"upcloud_server":             server.DataSourceServer(),
+			"upcloud_servers":            server.DataSourceServers(),
+			"upcloud_server_plans":       server.DataSourceServerPlans(),
 			"upcloud_ip_addresses":       ip.DataSourceIPAddresses(),
 			"upcloud_tags":               tag.DataSourceTags(),
 			"upcloud_storage":            storage.DataSourceStorage(),
@@ -131,9 +204,13 @@ func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{},
 
 	requestTimeout := time.Duration(d.Get("request_timeout_sec").(int)) * time.Second
 
-	config := Config{
+	cfg := Config{
 		Username: d.Get("username").(string),
 		Password: d.Get("password").(string),
+		Token:    d.Get("token").(string),
+	}
+	if err := cfg.loadProfile(d.Get("credentials_file").(string), d.Get("profile").(string)); err != nil {
+		return nil, diag.FromErr(err)
 	}
 
 	httpClient := retryablehttp.NewClient()
@@ -141,30 +218,100 @@ func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{},
 	httpClient.RetryWaitMax = time.Duration(d.Get("retry_wait_max_sec").(int)) * time.Second
 	httpClient.RetryMax = d.Get("retry_max").(int)
 
+	if caFile, ok := d.GetOk("ca_certificate_file"); ok {
+		if err := addCACertificates(httpClient.HTTPClient, caFile.(string)); err != nil {
+			return nil, diag.FromErr(err)
+		}
+	}
+
+	if cfg.Token != "" {
+		withToken(httpClient.HTTPClient, cfg.Token)
+	}
+
+	if os.Getenv(apiLogEnvVar) != "" {
+		withHTTPLogging(httpClient.HTTPClient)
+	}
+
+	withRateLimit(
+		httpClient.HTTPClient,
+		d.Get("requests_per_second").(float64),
+		d.Get("max_concurrent_requests").(int),
+		httpClient.RetryMax,
+		httpClient.RetryWaitMin,
+		httpClient.RetryWaitMax,
+	)
+
+	var opts []client.ConfigFn
+	if baseURL, ok := d.GetOk("api_base_url"); ok {
+		opts = append(opts, client.WithBaseURL(strings.TrimSuffix(baseURL.(string), "/")))
+	}
+
 	service := newUpCloudServiceConnection(
-		d.Get("username").(string),
-		d.Get("password").(string),
+		cfg.Username,
+		cfg.Password,
 		httpClient.HTTPClient,
 		requestTimeout,
+		opts...,
 	)
 
-	_, err := config.checkLogin(service)
+	_, err := cfg.checkLogin(service)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	return service, diags
+	defaultLabels := make(map[string]string)
+	for k, v := range d.Get("default_labels").(map[string]interface{}) {
+		defaultLabels[k] = v.(string)
+	}
+
+	return &config.Meta{
+		Service:       service,
+		DefaultLabels: defaultLabels,
+		DefaultZone:   d.Get("zone").(string),
+		ServerLocks:   mutexkv.New(),
+	}, diags
 }
 
-func newUpCloudServiceConnection(username, password string, httpClient *http.Client, requestTimeout time.Duration) *service.Service {
+func newUpCloudServiceConnection(username, password string, httpClient *http.Client, requestTimeout time.Duration, opts ...client.ConfigFn) *service.Service {
 	providerClient := client.New(
 		username,
 		password,
-		client.WithHTTPClient(httpClient),
-		client.WithTimeout(requestTimeout),
+		append([]client.ConfigFn{
+			client.WithHTTPClient(httpClient),
+			client.WithTimeout(requestTimeout),
+		}, opts...)...,
 	)
 
 	providerClient.UserAgent = fmt.Sprintf("terraform-provider-upcloud/%s", config.Version)
 
 	return service.New(providerClient)
 }
+
+// addCACertificates configures httpClient to trust the PEM encoded CA certificates in file in addition to the system
+// certificate pool.
+func addCACertificates(httpClient *http.Client, file string) error {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("unable to read CA certificate file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no PEM encoded certificates found in %s", file)
+	}
+
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unable to configure CA certificates for HTTP transport %T", httpClient.Transport)
+	}
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	httpClient.Transport = transport
+	return nil
+}
@@ -2,9 +2,18 @@ package upcloud
 
 import (
 	"context"
+	"encoding/pem"
+	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/router"
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/testing/fakeapi"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/client"
+	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -43,6 +52,119 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestNewUpCloudServiceConnection_fakeAPI(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	ctx := context.Background()
+	svc := newUpCloudServiceConnection(fakeapi.Username, fakeapi.Password, http.DefaultClient, 10*time.Second, client.WithBaseURL(api.URL))
+	cfg := Config{Username: fakeapi.Username, Password: fakeapi.Password}
+	if _, err := cfg.checkLogin(svc); err != nil {
+		t.Fatalf("checkLogin failed: %s", err)
+	}
+
+	meta := &config.Meta{Service: svc}
+	r := router.ResourceRouter()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"name": "fakeapi"})
+	if diags := r.CreateContext(ctx, d, meta); diags.HasError() {
+		t.Fatalf("create failed: %+v", diags)
+	}
+	if diags := r.ReadContext(ctx, d, meta); diags.HasError() || d.Get("name") != "fakeapi" {
+		t.Fatalf("read failed: %+v", diags)
+	}
+	id := d.Id()
+	if diags := r.DeleteContext(ctx, d, meta); diags.HasError() {
+		t.Fatalf("delete failed: %+v", diags)
+	}
+	if _, err := svc.GetRouterDetails(ctx, &request.GetRouterDetailsRequest{UUID: id}); err == nil {
+		t.Errorf("router %s was not deleted", id)
+	}
+}
+
+func TestProviderConfigure_apiBaseURL(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	p := Provider()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"username":     fakeapi.Username,
+		"password":     fakeapi.Password,
+		"api_base_url": api.URL + "/",
+	}))
+	if diags.HasError() {
+		t.Fatalf("configure failed: %+v", diags)
+	}
+}
+
+func TestProviderConfigure_caCertificateFile(t *testing.T) {
+	api := fakeapi.NewTLS()
+	defer api.Close()
+
+	raw := map[string]interface{}{
+		"username":     fakeapi.Username,
+		"password":     fakeapi.Password,
+		"api_base_url": api.URL,
+		"retry_max":    0,
+	}
+	if diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(raw)); !diags.HasError() {
+		t.Fatal("configure succeeded without trusting the API certificate")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: api.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	raw["ca_certificate_file"] = caFile
+	if diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(raw)); diags.HasError() {
+		t.Fatalf("configure failed: %+v", diags)
+	}
+}
+
+func TestProviderConfigure_defaultLabels(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	p := Provider()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"username":       fakeapi.Username,
+		"password":       fakeapi.Password,
+		"api_base_url":   api.URL,
+		"default_labels": map[string]interface{}{"team": "core", "env": "prod"},
+	}))
+	if diags.HasError() {
+		t.Fatalf("configure failed: %+v", diags)
+	}
+
+	meta, ok := p.Meta().(*config.Meta)
+	if !ok {
+		t.Fatalf("unexpected provider meta %T", p.Meta())
+	}
+	if meta.DefaultLabels["team"] != "core" || meta.DefaultLabels["env"] != "prod" || len(meta.DefaultLabels) != 2 {
+		t.Errorf("unexpected default labels %v", meta.DefaultLabels)
+	}
+}
+
+func TestProviderConfigure_zone(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	t.Setenv("UPCLOUD_ZONE", "fi-hel1")
+	p := Provider()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"username":     fakeapi.Username,
+		"password":     fakeapi.Password,
+		"api_base_url": api.URL,
+	}))
+	if diags.HasError() {
+		t.Fatalf("configure failed: %+v", diags)
+	}
+
+	if zone := p.Meta().(*config.Meta).DefaultZone; zone != "fi-hel1" {
+		t.Errorf("expected default zone fi-hel1, got %s", zone)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("UPCLOUD_USERNAME"); v == "" {
 		t.Fatal("UPCLOUD_USERNAME must be set for acceptance tests")
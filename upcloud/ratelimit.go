@@ -0,0 +1,186 @@
+package upcloud
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// tokenBucket limits the rate of events to rate events per second with bursts of at most burst events.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := math.Max(1, math.Ceil(rate))
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// rateLimitTransport limits the rate and concurrency of requests sent to the UpCloud API. Requests rejected with
+// 429 Too Many Requests are retried after the delay given in the Retry-After header.
+type rateLimitTransport struct {
+	base http.RoundTripper
+	// bucket limits the request rate, nil if rate is not limited.
+	bucket *tokenBucket
+	// inFlight limits the number of concurrent requests, nil if concurrency is not limited.
+	inFlight chan struct{}
+
+	retryMax     int
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+}
+
+func (t *rateLimitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(r)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= t.retryMax {
+			return resp, err
+		}
+
+		// Request body has already been consumed and can not be sent again.
+		if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+			return resp, err
+		}
+
+		wait := retryAfter(resp, t.backoff(attempt))
+		resp.Body.Close()
+		tflog.Warn(ctx, "UpCloud API rate limit exceeded, retrying request", map[string]interface{}{
+			"method": r.Method,
+			"url":    r.URL.String(),
+			"wait":   wait.String(),
+		})
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = r.Clone(ctx)
+			r.Body = body
+		}
+	}
+}
+
+func (t *rateLimitTransport) roundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	if t.inFlight != nil {
+		select {
+		case t.inFlight <- struct{}{}:
+			defer func() { <-t.inFlight }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if t.bucket != nil {
+		if err := t.bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.base.RoundTrip(r)
+}
+
+func (t *rateLimitTransport) backoff(attempt int) time.Duration {
+	wait := time.Duration(math.Pow(2, float64(attempt)) * float64(t.retryWaitMin))
+	if wait <= 0 || wait > t.retryWaitMax {
+		return t.retryWaitMax
+	}
+	return wait
+}
+
+// withRateLimit configures httpClient to send at most requestsPerSecond requests per second and to have at most
+// maxConcurrentRequests requests in flight at a time. Zero value disables the corresponding limit. Requests rejected
+// with 429 Too Many Requests are retried at most retryMax times.
+func withRateLimit(httpClient *http.Client, requestsPerSecond float64, maxConcurrentRequests, retryMax int, retryWaitMin, retryWaitMax time.Duration) {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &rateLimitTransport{
+		base:         base,
+		retryMax:     retryMax,
+		retryWaitMin: retryWaitMin,
+		retryWaitMax: retryWaitMax,
+	}
+	if requestsPerSecond > 0 {
+		t.bucket = newTokenBucket(requestsPerSecond)
+	}
+	if maxConcurrentRequests > 0 {
+		t.inFlight = make(chan struct{}, maxConcurrentRequests)
+	}
+	httpClient.Transport = t
+}
+
+// retryAfter returns the delay requested in the Retry-After header of resp, or fallback if the header is not set.
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return fallback
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+
+	return fallback
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
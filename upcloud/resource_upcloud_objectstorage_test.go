@@ -9,10 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/service/objectstorage"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -461,7 +461,7 @@ func verifyObjectStorageDoesNotExist(name string) resource.TestCheckFunc {
 				continue
 			}
 
-			client := testAccProvider.Meta().(*service.Service)
+			client := testAccProvider.Meta().(*config.Meta).Service
 			_, err := client.GetObjectStorageDetails(context.Background(), &request.GetObjectStorageDetailsRequest{
 				UUID: rs.Primary.ID,
 			})
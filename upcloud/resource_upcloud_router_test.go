@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -261,7 +261,7 @@ func testAccCheckRouterExists(resourceName string, router *upcloud.Router) resou
 		}
 
 		// Use the API SDK to locate the remote resource.
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		latest, err := client.GetRouterDetails(context.Background(), &request.GetRouterDetailsRequest{
 			UUID: rs.Primary.ID,
 		})
@@ -284,7 +284,7 @@ func testAccCheckRouterDoesntExist(resourceName string, router *upcloud.Router)
 		}
 
 		// Use the API SDK to locate the remote resource.
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		_, err := client.GetRouterDetails(context.Background(), &request.GetRouterDetailsRequest{
 			UUID: router.UUID,
 		})
@@ -311,7 +311,7 @@ func testAccCheckNetworkExists(resourceName string, network *upcloud.Network) re
 		}
 
 		// Use the API SDK to locate the remote resource.
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		latest, err := client.GetNetworkDetails(context.Background(), &request.GetNetworkDetailsRequest{
 			UUID: rs.Primary.ID,
 		})
@@ -343,7 +343,7 @@ func testAccCheckRouterDestroy(s *terraform.State) error {
 			continue
 		}
 
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		routers, err := client.GetRouters(context.Background())
 		if err != nil {
 			return fmt.Errorf("[WARN] Error listing routers when deleting upcloud router (%s): %s", rs.Primary.ID, err)
@@ -360,7 +360,7 @@ func testAccCheckRouterDestroy(s *terraform.State) error {
 }
 
 func testAccCheckRouterNetworkDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*service.Service)
+	client := testAccProvider.Meta().(*config.Meta).Service
 	for _, rs := range s.RootModule().Resources {
 		switch rs.Type {
 		case "upcloud_router":
@@ -6,8 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/UpCloudLtd/terraform-provider-upcloud/internal/config"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud"
-	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -87,7 +87,7 @@ func testAccCheckTagsExists(resourceName string, tags *upcloud.Tags) resource.Te
 		}
 
 		// Use the API SDK to locate the remote resource.
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		latest, err := client.GetTags(context.Background())
 		if err != nil {
 			return err
@@ -106,7 +106,7 @@ func testAccCheckTagDestroy(s *terraform.State) error {
 			continue
 		}
 
-		client := testAccProvider.Meta().(*service.Service)
+		client := testAccProvider.Meta().(*config.Meta).Service
 		tags, err := client.GetTags(context.Background())
 		if err != nil {
 			return fmt.Errorf(